@@ -16,8 +16,6 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
-	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
 )
 
@@ -30,28 +28,22 @@ var addidCmd = &cobra.Command{
 	Short: "Add a prefix or suffix to sequence names",
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		records := make(chan seqs.SeqRecord)
-		errs := make(chan error)
-
-		go seqs.ReadFastaRecords(inputReader, records, errs)
-
-		for records != nil && errs != nil {
-			select {
-			case record := <-records:
-				output, err := record.Seq.FormatSeq(outputLineWidth)
-				if err != nil {
-					return err
-				}
-				_, err = fmt.Fprintf(outputWriter, ">%s\n%s\n", prefix+record.Name+suffix, output)
-				if err != nil {
-					return err
-				}
-			case err := <-errs:
+		reader := newReader()
+		writer := newWriter(reader.Format())
+
+		for reader.Scan() {
+			record := reader.Record()
+			setRecordKey(&record, prefix+recordKey(record)+suffix)
+			if err := writer.Write(record); err != nil {
 				return err
 			}
 		}
 
-		return nil
+		if err := reader.Err(); err != nil {
+			return err
+		}
+
+		return writer.Flush()
 	},
 }
 
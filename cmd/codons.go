@@ -0,0 +1,187 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lucblassel/fastago/pkg/gencode"
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var codonsMode string
+var codonTable int
+
+// codonsCmd represents the codons command
+var codonsCmd = &cobra.Command{
+	Use:   "codons",
+	Short: "get codon usage of coding sequences in fasta file",
+	Long: `By default this command outputs the codon usage over all sequences, which are read as coding sequences
+	in the first frame. For each codon, the amino acid it codes for, its count, its frequency per thousand codons
+	and its relative synonymous codon usage (RSCU) are given. Codons containing ambiguous bases are not counted.
+	The genetic code is selected by its NCBI number with -t/--table (1, the standard code, by default).
+	A warning is printed for sequences whose length is not a multiple of 3 or that contain internal stop codons.
+	set the -m/--mode flag to either:
+		- total: will display the codon usage over all sequences
+		- each: will display the codon usage of each sequence`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		code, err := gencode.ByID(codonTable)
+		if err != nil {
+			return err
+		}
+
+		reader := newReader()
+
+		switch codonsMode {
+		case "total":
+			return getTotalCodons(reader, code)
+		case "each":
+			return getEachCodons(reader, code)
+		default:
+			return fmt.Errorf(
+				"mode %s not recognized.\n"+
+					"The mode must be one of the following values: "+
+					"'total' 'each'", codonsMode)
+		}
+	},
+}
+
+// init adds command to stats and deals with flags
+func init() {
+	statsCmd.AddCommand(codonsCmd)
+	codonsCmd.Flags().StringVarP(&codonsMode, "mode", "m", "total", "How to display codon usage")
+	codonsCmd.Flags().IntVarP(&codonTable, "table", "t", 1, "NCBI genetic code number")
+}
+
+// codonCounts holds the number of occurrences of each codon, in the NCBI order
+type codonCounts [64]int
+
+// countCodons adds the codons of a record to the counts, warning about sequences that do not look like
+// complete coding sequences
+func countCodons(record seqs.SeqRecord, code *gencode.Code, counts *codonCounts) {
+	seq := string(record.Seq)
+	if len(seq)%3 != 0 {
+		fmt.Fprintf(os.Stderr, "warning: length of %s (%d) is not a multiple of 3\n", recordKey(record), len(seq))
+	}
+
+	for i := 0; i+3 <= len(seq); i += 3 {
+		index := gencode.CodonIndex(seq[i : i+3])
+		if index < 0 {
+			continue
+		}
+		if code.AminoAcid(index) == gencode.Stop && i+6 <= len(seq) {
+			fmt.Fprintf(os.Stderr, "warning: %s contains an internal stop codon at position %d\n", recordKey(record), i+1)
+		}
+		counts[index]++
+	}
+}
+
+// codonColumns are the columns of codon usage tables
+var codonColumns = []column{
+	{Key: "codon", Title: "Codon"},
+	{Key: "amino_acid", Title: "Amino acid"},
+	{Key: "count", Title: "Count"},
+	{Key: "per_thousand", Title: "Per thousand"},
+	{Key: "rscu", Title: "RSCU"},
+}
+
+// codonUsage returns the usage rows of all 64 codons, made of the codon, its amino acid, count,
+// frequency per thousand codons and RSCU. The RSCU of a codon is its count divided by the
+// average count of the codons coding for the same amino acid, it is 0 if none of them is used.
+func codonUsage(counts codonCounts, code *gencode.Code) [][]interface{} {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+
+	rows := make([][]interface{}, 0, 64)
+	for index, codon := range gencode.Codons() {
+		synonyms := code.Synonyms(index)
+		synonymCount := 0
+		for _, synonym := range synonyms {
+			synonymCount += counts[synonym]
+		}
+
+		perThousand, rscu := 0.0, 0.0
+		if total > 0 {
+			perThousand = 1000 * float64(counts[index]) / float64(total)
+		}
+		if synonymCount > 0 {
+			rscu = float64(counts[index]) * float64(len(synonyms)) / float64(synonymCount)
+		}
+
+		rows = append(rows, []interface{}{codon, string(code.AminoAcid(index)), counts[index], perThousand, rscu})
+	}
+	return rows
+}
+
+// getTotalCodons writes the codon usage over all sequences to the output stream
+func getTotalCodons(reader *seqs.Reader, code *gencode.Code) error {
+	counts := make(map[string]*codonCounts)
+
+	for reader.Scan() {
+		record := reader.Record()
+		group := statsGroup(record)
+		if counts[group] == nil {
+			counts[group] = &codonCounts{}
+		}
+		countCodons(record, code, counts[group])
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	results := newResultWriter(statsColumns(codonColumns...)...)
+	results.precision = 2
+	for _, group := range statsGroups() {
+		groupCounts := counts[group]
+		if groupCounts == nil {
+			groupCounts = &codonCounts{}
+		}
+		for _, row := range codonUsage(*groupCounts, code) {
+			if err := results.Write(statsRow(group, row...)...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return results.Close()
+}
+
+// getEachCodons writes the codon usage of each sequence to the output stream, one row per codon
+func getEachCodons(reader *seqs.Reader, code *gencode.Code) error {
+	results := newResultWriter(statsColumns(append([]column{{Key: "name", Title: "Name"}}, codonColumns...)...)...)
+	results.precision = 2
+
+	for reader.Scan() {
+		record := reader.Record()
+		var counts codonCounts
+		countCodons(record, code, &counts)
+
+		for _, row := range codonUsage(counts, code) {
+			if err := results.Write(statsRow(record.Source, append([]interface{}{recordKey(record)}, row...)...)...); err != nil {
+				return err
+			}
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return results.Close()
+}
@@ -0,0 +1,291 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var complexityMode string
+var dustWindow int
+var dustStep int
+var dustThreshold float64
+var maxWordSize int
+
+// complexityCmd represents the complexity command
+var complexityCmd = &cobra.Command{
+	Use:   "complexity",
+	Short: "get sequence complexity and low-complexity regions of sequences in fasta file",
+	Long: `By default this command outputs for each sequence its Shannon entropy in bits, its linguistic complexity
+	and its DUST score. The linguistic complexity is the number of distinct words of 1 to -k/--max-word
+	nucleotides in the sequence, divided by the largest possible number of such words. The DUST score
+	measures how often nucleotide triplets are repeated, it is computed in windows of --window bases
+	moving by -s/--step bases (half the window by default) and averaged over the sequence.
+	set the -m/--mode flag to either:
+		- each: will display the complexity of each sequence
+		- bed: will display the low-complexity regions of each sequence, made of the windows with a DUST
+		  score above --threshold, as BED intervals with the highest DUST score of each region`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dustWindow < 4 {
+			return errors.New("window size must be >= 4")
+		}
+		if dustStep < 0 {
+			return errors.New("step size must be > 0")
+		}
+		if dustStep == 0 {
+			dustStep = dustWindow / 2
+		}
+		if maxWordSize < 1 || maxWordSize > 12 {
+			return fmt.Errorf("maximum word size must be between 1 and 12, got %d", maxWordSize)
+		}
+
+		reader := newReader()
+
+		switch complexityMode {
+		case "each":
+			return getEachComplexity(reader)
+		case "bed":
+			return getLowComplexity(reader)
+		default:
+			return fmt.Errorf(
+				"mode %s not recognized.\n"+
+					"The mode must be one of the following values: "+
+					"'each' 'bed'", complexityMode)
+		}
+	},
+}
+
+// init adds command to stats and deals with flags
+func init() {
+	statsCmd.AddCommand(complexityCmd)
+	complexityCmd.Flags().StringVarP(&complexityMode, "mode", "m", "each", "How to display complexity")
+	complexityCmd.Flags().IntVar(&dustWindow, "window", 64, "Size of DUST windows")
+	complexityCmd.Flags().IntVarP(&dustStep, "step", "s", 0, "Number of bases between the starts of consecutive DUST windows (default is half the window size)")
+	complexityCmd.Flags().Float64Var(&dustThreshold, "threshold", 7, "DUST score above which a window has a low complexity")
+	complexityCmd.Flags().IntVarP(&maxWordSize, "max-word", "k", 6, "Largest word size used for linguistic complexity")
+}
+
+// shannonEntropy returns the Shannon entropy in bits of the uppercased characters of a sequence
+func shannonEntropy(seq seqs.Seq) float64 {
+	var counts [256]int
+	for i := 0; i < len(seq); i++ {
+		char := seq[i]
+		if 'a' <= char && char <= 'z' {
+			char -= 'a' - 'A'
+		}
+		counts[char]++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / float64(len(seq))
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+// linguisticComplexity returns the number of distinct words of 1 to maxWord nucleotides in the sequence,
+// divided by the largest possible number of such words for a sequence of this length. Words overlapping
+// a character that is not a nucleotide are skipped.
+func linguisticComplexity(seq seqs.Seq, maxWord int) float64 {
+	observed, possible := 0, 0
+	for k := 1; k <= maxWord && k <= len(seq); k++ {
+		seen := make([]bool, 1<<(2*uint(k)))
+		mask := uint64(1)<<(2*uint(k)) - 1
+		var word uint64
+		valid := 0
+		for i := 0; i < len(seq); i++ {
+			code := baseCodes[seq[i]]
+			if code < 0 {
+				valid = 0
+				continue
+			}
+			word = (word<<2 | uint64(code)) & mask
+			if valid++; valid >= k && !seen[word] {
+				seen[word] = true
+				observed++
+			}
+		}
+
+		if len(seen) < len(seq)-k+1 {
+			possible += len(seen)
+		} else {
+			possible += len(seq) - k + 1
+		}
+	}
+
+	if possible == 0 {
+		return 0
+	}
+	return float64(observed) / float64(possible)
+}
+
+// tripletWindow counts the nucleotide triplets of a DUST window
+type tripletWindow struct {
+	counts   [64]int
+	pairs    int
+	triplets int
+}
+
+// tripletCode returns the 6-bit encoding of the triplet starting at i, or -1 if it contains a character
+// that is not a nucleotide
+func tripletCode(seq seqs.Seq, i int) int {
+	code := 0
+	for j := i; j < i+3; j++ {
+		base := baseCodes[seq[j]]
+		if base < 0 {
+			return -1
+		}
+		code = code<<2 | int(base)
+	}
+	return code
+}
+
+// update adds (delta = 1) or removes (delta = -1) the triplet starting at i from the window,
+// keeping track of the number of pairs of identical triplets
+func (window *tripletWindow) update(seq seqs.Seq, i int, delta int) {
+	code := tripletCode(seq, i)
+	if code < 0 {
+		return
+	}
+	if delta > 0 {
+		window.pairs += window.counts[code]
+		window.counts[code]++
+	} else {
+		window.counts[code]--
+		window.pairs -= window.counts[code]
+	}
+	window.triplets += delta
+}
+
+// score returns the DUST score of the window: the number of pairs of identical triplets
+// divided by the number of triplets minus one
+func (window *tripletWindow) score() float64 {
+	if window.triplets < 2 {
+		return 0
+	}
+	return float64(window.pairs) / float64(window.triplets-1)
+}
+
+// dustWindows computes the DUST score of windows sliding along the sequence and calls `f` with the
+// coordinates and score of each of them. Sequences shorter than a window make a single window.
+func dustWindows(seq seqs.Seq, f func(start int, end int, score float64) error) error {
+	var window tripletWindow
+	return slideWindows(len(seq), dustWindow, dustStep, 3,
+		func(i int) { window.update(seq, i, 1) },
+		func(i int) { window.update(seq, i, -1) },
+		func(start int, end int) error { return f(start, end, window.score()) })
+}
+
+// getEachComplexity writes the entropy, linguistic complexity and mean DUST score of each sequence
+// to the output stream
+func getEachComplexity(reader *seqs.Reader) error {
+	results := newResultWriter(statsColumns(
+		column{Key: "name", Title: "Name"},
+		column{Key: "length", Title: "Length"},
+		column{Key: "entropy", Title: "Entropy"},
+		column{Key: "linguistic_complexity", Title: "Linguistic complexity"},
+		column{Key: "dust", Title: "DUST score"},
+	)...)
+	results.precision = 4
+
+	for reader.Scan() {
+		record := reader.Record()
+
+		total, windows := 0.0, 0
+		_ = dustWindows(record.Seq, func(start int, end int, score float64) error {
+			total += score
+			windows++
+			return nil
+		})
+		dust := 0.0
+		if windows > 0 {
+			dust = total / float64(windows)
+		}
+
+		err := results.Write(statsRow(record.Source,
+			recordKey(record), record.Seq.Length(),
+			shannonEntropy(record.Seq), linguisticComplexity(record.Seq, maxWordSize), dust,
+		)...)
+		if err != nil {
+			return err
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return results.Close()
+}
+
+// getLowComplexity writes the low-complexity regions of each sequence to the output stream as BED intervals.
+// Overlapping or adjacent windows with a DUST score above the threshold are merged in a single region.
+func getLowComplexity(reader *seqs.Reader) error {
+	results := newResultWriter(statsColumns(
+		column{Key: "name", Title: "Name"},
+		column{Key: "start", Title: "Start"},
+		column{Key: "end", Title: "End"},
+		column{Key: "dust", Title: "DUST score"},
+	)...)
+	results.precision = 4
+
+	for reader.Scan() {
+		record := reader.Record()
+		name := recordKey(record)
+
+		regionStart, regionEnd, regionScore := 0, -1, 0.0
+		writeRegion := func() error {
+			if regionEnd < 0 {
+				return nil
+			}
+			return results.Write(statsRow(record.Source, name, regionStart, regionEnd, regionScore)...)
+		}
+
+		err := dustWindows(record.Seq, func(start int, end int, score float64) error {
+			if score <= dustThreshold {
+				return nil
+			}
+			if regionEnd >= start {
+				regionEnd = end
+				regionScore = math.Max(regionScore, score)
+				return nil
+			}
+			if err := writeRegion(); err != nil {
+				return err
+			}
+			regionStart, regionEnd, regionScore = start, end, score
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeRegion(); err != nil {
+			return err
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return results.Close()
+}
@@ -16,10 +16,8 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
 	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
-	"io"
 )
 
 // countCmd represents the count command
@@ -27,13 +25,17 @@ var countCmd = &cobra.Command{
 	Use:   "count",
 	Short: "count the number of sequences in a fasta file",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		count, err := countSeqs(inputReader)
+		counts, err := countSeqs(newReader())
 		if err != nil {
 			return err
 		}
-		_, err = fmt.Fprintln(outputWriter, count)
-		return err
+		results := newResultWriter(statsColumns(column{Key: "count", Title: "Sequence count"})...)
+		for _, group := range statsGroups() {
+			if err := results.Write(statsRow(group, counts[group])...); err != nil {
+				return err
+			}
+		}
+		return results.Close()
 	},
 }
 
@@ -42,26 +44,15 @@ func init() {
 	statsCmd.AddCommand(countCmd)
 }
 
-// countSeqs returns the number of seqRecord elements in the input stream
-func countSeqs(input io.Reader) (int, error) {
-	records := make(chan seqs.SeqRecord)
-	errs := make(chan error)
-	go seqs.ReadFastaRecords(input, records, errs)
-
-	count := 0
-
-	for records != nil && errs != nil {
-		select {
-		case <-records:
-			count++
-		case err := <-errs:
-			if err != nil {
-				return 0, err
-			}
-			return count, nil
-
-		}
+// countSeqs returns the number of seqRecord elements in the input stream for each statistics group
+func countSeqs(reader *seqs.Reader) (map[string]int, error) {
+	counts := make(map[string]int)
+	for reader.Scan() {
+		counts[statsGroup(reader.Record())]++
+	}
+	if err := reader.Err(); err != nil {
+		return nil, err
 	}
 
-	return count, nil
+	return counts, nil
 }
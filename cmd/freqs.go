@@ -17,15 +17,18 @@ package cmd
 
 import (
 	"fmt"
-	"io"
 	"sort"
-	"strings"
 
 	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
 )
 
 var freqsMode string
+var freqsCounts bool
+var freqsPrecision int
+var caseSensitive bool
+var freqGroups []string
+var freqsSymbols string
 
 // freqsCmd represents the frequency command
 var freqsCmd = &cobra.Command{
@@ -34,21 +37,45 @@ var freqsCmd = &cobra.Command{
 	Long: `By default this command outputs the frequencies averaged over sequences. 
 	It is also possible to retreive the frequencies per sequence.
 	set the -m/--mode flag to:
-		- each: will display the frequencies of each sequence`,
+		- each: will display the frequencies of each sequence, with one column per symbol
+	Characters are uppercased unless --case-sensitive is set, and --counts displays the number of
+	occurrences of each symbol instead of its frequency. With --group, characters are counted in groups
+	instead of individually, the available groups are:
+		- gc: G, C
+		- at: A, T, U
+		- n: N
+		- gap: '-', '.'
+		- ambiguous: IUPAC ambiguity codes other than N
+	With --symbols, only the given characters are displayed. In the each mode, when neither --group nor
+	--symbols is set the columns are all the symbols of the input, which are only known at the end of the
+	input: the counts of every sequence are then kept in memory until all sequences are read.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		for i, group := range freqGroups {
+			if _, ok := freqGroupClasses[group]; !ok {
+				return fmt.Errorf("group %s not recognized, must be one of 'gc' 'at' 'n' 'gap' 'ambiguous'", group)
+			}
+			for _, previous := range freqGroups[:i] {
+				if previous == group {
+					return fmt.Errorf("group %s is given more than once", group)
+				}
+			}
+		}
+		if len(freqGroups) > 0 && freqsSymbols != "" {
+			return fmt.Errorf("--group and --symbols cannot be used together")
+		}
+		if freqsPrecision < 0 {
+			return fmt.Errorf("precision must be >= 0, got %d", freqsPrecision)
+		}
 
-		records := make(chan seqs.SeqRecord)
-		errs := make(chan error)
-
-		go seqs.ReadFastaRecords(inputReader, records, errs)
+		reader := newReader()
 
 		var err error
 
 		switch freqsMode {
 		case "each":
-			err = getEachFreqs(records, errs, outputWriter)
+			err = getEachFreqs(reader)
 		default:
-			err = getAverageFreqs(records, errs, outputWriter)
+			err = getAverageFreqs(reader)
 		}
 
 		return err
@@ -59,97 +86,231 @@ func init() {
 	statsCmd.AddCommand(freqsCmd)
 
 	freqsCmd.Flags().StringVarP(&freqsMode, "mode", "m", "", "How to display frequencies")
+	freqsCmd.Flags().BoolVar(&freqsCounts, "counts", false, "Display the number of occurrences instead of frequencies")
+	freqsCmd.Flags().IntVar(&freqsPrecision, "precision", 2, "Number of decimals of frequencies")
+	freqsCmd.Flags().BoolVar(&caseSensitive, "case-sensitive", false, "Count upper and lower case characters separately")
+	freqsCmd.Flags().StringSliceVar(&freqGroups, "group", nil, "Comma separated groups of characters to count together [gc, at, n, gap, ambiguous]")
+	freqsCmd.Flags().StringVar(&freqsSymbols, "symbols", "", "Characters to display (e.g. ACGT-), sequences are then written as they are read in the each mode")
+}
+
+// gapBase is the category of alignment gap characters, it is only used to group frequencies
+const gapBase baseClass = 255
+
+// freqGroupClasses are the base categories counted by each group of the --group flag
+var freqGroupClasses = map[string]baseClass{
+	"gc":        gcBase,
+	"at":        atBase,
+	"n":         nBase,
+	"gap":       gapBase,
+	"ambiguous": ambiguousBase,
+}
+
+// freqSymbol returns the symbol under which a character is counted, or "" if it is not part of
+// any of the groups given with --group
+func freqSymbol(char byte) string {
+	if len(freqGroups) == 0 {
+		if !caseSensitive && 'a' <= char && char <= 'z' {
+			char -= 'a' - 'A'
+		}
+		return string(char)
+	}
+
+	class := baseClasses[char]
+	if char == '-' || char == '.' {
+		class = gapBase
+	}
+	for _, group := range freqGroups {
+		if freqGroupClasses[group] == class {
+			return group
+		}
+	}
+	return ""
 }
 
-func getFreqs(record seqs.SeqRecord, output io.Writer) error {
-	var totalCount float64 = 0
-	var countmap = make(map[rune]float64)
+// freqCounts holds the number of occurrences of each character
+type freqCounts [256]int
+
+// add counts the characters of a sequence
+func (counts *freqCounts) add(seq seqs.Seq) {
+	for i := 0; i < len(seq); i++ {
+		counts[seq[i]]++
+	}
+}
 
-	for _, char := range strings.ToUpper(string(record.Seq)) {
-		_, isPresent := countmap[char]
-		if isPresent {
-			countmap[char] += 1
-		} else {
-			countmap[char] = 1
+// symbolCounts returns the number of occurrences of each symbol and the number of characters counted
+func (counts *freqCounts) symbolCounts() (map[string]int, int) {
+	countmap := make(map[string]int)
+	totalCount := 0
+	for char, count := range counts {
+		if count == 0 {
+			continue
+		}
+		if symbol := freqSymbol(byte(char)); symbol != "" {
+			countmap[symbol] += count
 		}
-		totalCount += 1
+		totalCount += count
 	}
+	return countmap, totalCount
+}
 
-	for char, count := range countmap {
-		countmap[char] = count / totalCount
+// freqValue returns the value displayed for a symbol: its count if --counts is set, or its frequency
+func freqValue(count int, totalCount int) interface{} {
+	if freqsCounts {
+		return count
+	}
+	if totalCount == 0 {
+		return 0.0
 	}
+	return float64(count) / float64(totalCount)
+}
+
+// freqRecord holds the symbol counts of a sequence
+type freqRecord struct {
+	source     string
+	name       string
+	countmap   map[string]int
+	totalCount int
+}
 
-	fmt.Fprintf(output, "%s\t", record.Name)
-	err := printmap(countmap, "  ", "\t\t", output)
-	fmt.Fprintf(output, "\n")
+// newFreqRecord counts the symbols of a sequence
+func newFreqRecord(record seqs.SeqRecord) freqRecord {
+	var counts freqCounts
+	counts.add(record.Seq)
+	countmap, totalCount := counts.symbolCounts()
+	return freqRecord{record.Source, recordKey(record), countmap, totalCount}
+}
 
-	return err
+// newEachFreqsWriter returns the resultWriter of the each mode, with one column per symbol
+func newEachFreqsWriter(symbols []string) *resultWriter {
+	columns := []column{{Key: "name", Title: "Name"}}
+	for _, symbol := range symbols {
+		columns = append(columns, column{Key: symbol, Title: symbol})
+	}
+	results := newResultWriter(statsColumns(columns...)...)
+	results.precision = freqsPrecision
+	results.header = true
+	return results
 }
 
-func getEachFreqs(records chan seqs.SeqRecord, errs chan error, output io.Writer) error {
+// writeFreqRecord writes the value of each symbol for a sequence
+func writeFreqRecord(results *resultWriter, symbols []string, record freqRecord) error {
+	values := []interface{}{record.name}
+	for _, symbol := range symbols {
+		values = append(values, freqValue(record.countmap[symbol], record.totalCount))
+	}
+	return results.Write(statsRow(record.source, values...)...)
+}
 
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			err := getFreqs(record, output)
-			if err != nil {
+// getEachFreqs writes the symbol frequencies of each sequence to the output stream, one column per symbol.
+// Sequences are written as they are read when the symbols are given with --group or --symbols, otherwise
+// symbols are only known once all sequences are read, so the counts of all sequences are kept in memory.
+func getEachFreqs(reader *seqs.Reader) error {
+	if symbols := givenFreqSymbols(); symbols != nil {
+		results := newEachFreqsWriter(symbols)
+		for reader.Scan() {
+			if err := writeFreqRecord(results, symbols, newFreqRecord(reader.Record())); err != nil {
 				return err
 			}
-		case err := <-errs:
+		}
+		if err := reader.Err(); err != nil {
 			return err
 		}
+		return results.Close()
 	}
 
-	return nil
-}
+	var records []freqRecord
+	allSymbols := make(map[string]int)
 
-func getAverageFreqs(records chan seqs.SeqRecord, errs chan error, output io.Writer) error {
-	var totalCount float64 = 0
-	var countmap = make(map[rune]float64)
-
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			for _, char := range strings.ToUpper(string(record.Seq)) {
-				_, isPresent := countmap[char]
-				if isPresent {
-					countmap[char] += 1
-				} else {
-					countmap[char] = 1
-				}
-				totalCount += 1
-			}
-		case err := <-errs:
-			if err != nil {
-				return err
-			}
-			for char, count := range countmap {
-				countmap[char] = count / totalCount
-			}
-			err = printmap(countmap, "\t", "\n", output)
+	for reader.Scan() {
+		record := newFreqRecord(reader.Record())
+		for symbol := range record.countmap {
+			allSymbols[symbol] = 1
+		}
+		records = append(records, record)
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	symbols := freqSymbols(allSymbols)
+	results := newEachFreqsWriter(symbols)
+	for _, record := range records {
+		if err := writeFreqRecord(results, symbols, record); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return results.Close()
 }
 
-func printmap(myMap map[rune]float64, sep string, endline string, output io.Writer) error {
-	keys := make([]rune, 0, len(myMap))
-	for k := range myMap {
-		keys = append(keys, k)
+// getAverageFreqs writes the symbol frequencies over all sequences to the output stream
+func getAverageFreqs(reader *seqs.Reader) error {
+	counts := make(map[string]*freqCounts)
+
+	for reader.Scan() {
+		group := statsGroup(reader.Record())
+		if counts[group] == nil {
+			counts[group] = new(freqCounts)
+		}
+		counts[group].add(reader.Record().Seq)
+	}
+	if err := reader.Err(); err != nil {
+		return err
 	}
-	sort.Slice(keys, func(i, j int) bool {
-		return keys[i] < keys[j]
-	})
 
-	for _, k := range keys {
-		_, err := fmt.Fprintf(output, "%s%s%.2f%s", string(k), sep, myMap[k], endline)
-		if err != nil {
-			return err
+	valueColumn := column{Key: "frequency", Title: "Frequency"}
+	if freqsCounts {
+		valueColumn = column{Key: "count", Title: "Count"}
+	}
+	results := newResultWriter(statsColumns(column{Key: "symbol", Title: "Symbol"}, valueColumn)...)
+	results.precision = freqsPrecision
+
+	for _, group := range statsGroups() {
+		if counts[group] == nil {
+			counts[group] = new(freqCounts)
+		}
+		countmap, totalCount := counts[group].symbolCounts()
+		for _, symbol := range freqSymbols(countmap) {
+			if err := results.Write(statsRow(group, symbol, freqValue(countmap[symbol], totalCount))...); err != nil {
+				return err
+			}
 		}
 	}
 
-	return nil
+	return results.Close()
+}
+
+// givenFreqSymbols returns the symbols given with --group or --symbols in order, or nil if neither is set.
+// Characters of --symbols that are counted under the same symbol, such as 'a' and 'A', are only kept once.
+func givenFreqSymbols() []string {
+	if len(freqGroups) > 0 {
+		return freqGroups
+	}
+	if freqsSymbols == "" {
+		return nil
+	}
+	symbols := make([]string, 0, len(freqsSymbols))
+	seen := make(map[string]bool)
+	for i := 0; i < len(freqsSymbols); i++ {
+		symbol := freqSymbol(freqsSymbols[i])
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
 
+// freqSymbols returns the symbols to display for a count map: the symbols given with --group or --symbols,
+// or the symbols of the count map in increasing order
+func freqSymbols(countmap map[string]int) []string {
+	if symbols := givenFreqSymbols(); symbols != nil {
+		return symbols
+	}
+	keys := make([]string, 0, len(countmap))
+	for k := range countmap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,243 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var gcWindow int
+var gcStep int
+var gcTrack string
+
+// gcCmd represents the gc command
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "get GC content and skews of sequences in fasta file",
+	Long: `By default this command outputs, for each sequence, its GC percentage, GC skew (G-C)/(G+C),
+	AT skew (A-T)/(A+T) and the fraction of N bases. The GC percentage is computed over unambiguous bases.
+	With -w/--window, the statistics are computed in sliding windows along each sequence,
+	moving by -s/--step bases (the window size by default), and written as a BED-like track
+	with 0-based start and exclusive end coordinates. The cumulative GC skew is also given, to locate
+	the replication origin and terminus of bacterial chromosomes.
+	Set -t/--track to a single statistic (gc, gc_skew, at_skew, n_fraction, cumulative_gc_skew)
+	to write a bedGraph track instead.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if gcWindow < 0 || gcStep < 0 {
+			return errors.New("window and step sizes must be > 0")
+		}
+		if gcTrack != "" && gcWindow == 0 {
+			return errors.New("a window size must be given to write a track")
+		}
+		if gcStep == 0 {
+			gcStep = gcWindow
+		}
+
+		reader := newReader()
+		if gcWindow > 0 {
+			return getWindowGC(reader)
+		}
+		return getEachGC(reader)
+	},
+}
+
+// init adds command to stats and deals with flags
+func init() {
+	statsCmd.AddCommand(gcCmd)
+	gcCmd.Flags().IntVar(&gcWindow, "window", 0, "Size of sliding windows (0 to compute statistics on whole sequences)")
+	gcCmd.Flags().IntVarP(&gcStep, "step", "s", 0, "Number of bases between the starts of consecutive windows (default is the window size)")
+	gcCmd.Flags().StringVarP(&gcTrack, "track", "t", "", "Only write this statistic, as a bedGraph track")
+}
+
+// gcCounts holds the number of each nucleotide in a sequence or window
+type gcCounts struct {
+	a, c, g, t, n int
+}
+
+// add adds `delta` occurrences of a character to the counts, U is counted as T
+func (counts *gcCounts) add(char byte, delta int) {
+	switch char {
+	case 'A', 'a':
+		counts.a += delta
+	case 'C', 'c':
+		counts.c += delta
+	case 'G', 'g':
+		counts.g += delta
+	case 'T', 't', 'U', 'u':
+		counts.t += delta
+	case 'N', 'n':
+		counts.n += delta
+	}
+}
+
+// skew returns (x-y)/(x+y), or 0 if both are 0
+func skew(x int, y int) float64 {
+	if x+y == 0 {
+		return 0
+	}
+	return float64(x-y) / float64(x+y)
+}
+
+// gcPercent returns the percentage of G and C among unambiguous bases
+func (counts gcCounts) gcPercent() float64 {
+	total := counts.a + counts.c + counts.g + counts.t
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(counts.g+counts.c) / float64(total)
+}
+
+// nFraction returns the fraction of N bases among `length` characters
+func (counts gcCounts) nFraction(length int) float64 {
+	if length == 0 {
+		return 0
+	}
+	return float64(counts.n) / float64(length)
+}
+
+// gcColumns are the statistics computed on each sequence or window
+var gcColumns = []column{
+	{Key: "gc_percent", Title: "GC (%)"},
+	{Key: "gc_skew", Title: "GC skew"},
+	{Key: "at_skew", Title: "AT skew"},
+	{Key: "n_fraction", Title: "N fraction"},
+}
+
+// getEachGC writes the GC statistics of each sequence to the output stream
+func getEachGC(reader *seqs.Reader) error {
+	columns := append([]column{{Key: "name", Title: "Name"}, {Key: "length", Title: "Length"}}, gcColumns...)
+	results := newResultWriter(statsColumns(columns...)...)
+	results.precision = 4
+
+	for reader.Scan() {
+		record := reader.Record()
+		var counts gcCounts
+		for i := 0; i < len(record.Seq); i++ {
+			counts.add(record.Seq[i], 1)
+		}
+
+		err := results.Write(statsRow(record.Source,
+			recordKey(record), record.Seq.Length(),
+			counts.gcPercent(), skew(counts.g, counts.c), skew(counts.a, counts.t), counts.nFraction(record.Seq.Length()),
+		)...)
+		if err != nil {
+			return err
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return results.Close()
+}
+
+// slideWindows slides windows of `size` characters by `step` characters along a sequence of `length`
+// characters, the last window being cut at the end of the sequence. Windows are described by the words
+// of `width` characters they contain: `add` and `remove` are called with the start of the words entering
+// and leaving the window, and `emit` with the coordinates of each window once it is filled.
+// Window contents are updated incrementally, so each sequence is processed in linear time.
+func slideWindows(length int, size int, step int, width int, add func(i int), remove func(i int), emit func(start int, end int) error) error {
+	start, end := 0, 0
+	for start < length {
+		windowEnd := start + size
+		if windowEnd > length {
+			windowEnd = length
+		}
+		for ; end < windowEnd; end++ {
+			if end-width+1 >= start {
+				add(end - width + 1)
+			}
+		}
+
+		if err := emit(start, end); err != nil {
+			return err
+		}
+
+		if end == length {
+			break
+		}
+		next := start + step
+		for i := start; i < next && i+width-1 < end; i++ {
+			remove(i)
+		}
+		if next > end {
+			end = next
+		}
+		start = next
+	}
+	return nil
+}
+
+// getWindowGC writes the GC statistics of sliding windows along each sequence to the output stream
+func getWindowGC(reader *seqs.Reader) error {
+	statistics := append(append([]column{}, gcColumns...), column{Key: "cumulative_gc_skew", Title: "Cumulative GC skew"})
+	trackIndex := -1
+	if gcTrack != "" {
+		for i, col := range statistics {
+			if col.Key == gcTrack || (gcTrack == "gc" && col.Key == "gc_percent") {
+				trackIndex = i
+			}
+		}
+		if trackIndex < 0 {
+			return fmt.Errorf("track %s not recognized, must be one of 'gc' 'gc_skew' 'at_skew' 'n_fraction' 'cumulative_gc_skew'", gcTrack)
+		}
+		statistics = statistics[trackIndex : trackIndex+1]
+	}
+
+	columns := append([]column{
+		{Key: "name", Title: "Name"},
+		{Key: "start", Title: "Start"},
+		{Key: "end", Title: "End"},
+	}, statistics...)
+	results := newResultWriter(statsColumns(columns...)...)
+	results.precision = 4
+
+	for reader.Scan() {
+		record := reader.Record()
+		seq := record.Seq
+
+		var counts gcCounts
+		cumulativeSkew := 0.0
+		err := slideWindows(len(seq), gcWindow, gcStep, 1,
+			func(i int) { counts.add(seq[i], 1) },
+			func(i int) { counts.add(seq[i], -1) },
+			func(start int, end int) error {
+				gcSkew := skew(counts.g, counts.c)
+				cumulativeSkew += gcSkew
+				values := []interface{}{
+					counts.gcPercent(), gcSkew, skew(counts.a, counts.t), counts.nFraction(end - start), cumulativeSkew,
+				}
+				if trackIndex >= 0 {
+					values = values[trackIndex : trackIndex+1]
+				}
+
+				row := append([]interface{}{recordKey(record), start, end}, values...)
+				return results.Write(statsRow(record.Source, row...)...)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return results.Close()
+}
@@ -0,0 +1,256 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var histMode string
+var histBins int
+var histLog bool
+var histQuantiles []float64
+
+// chartWidth is the number of characters of the longest bar in histogram charts
+const chartWidth = 50
+
+// histCmd represents the hist command
+var histCmd = &cobra.Command{
+	Use:   "hist",
+	Short: "get the distribution of sequence lengths",
+	Long: `This command bins sequence lengths and reports the distribution of lengths.
+	Bins are of equal width, or equal width on a log scale with --log.
+	set the -m/--mode flag to either:
+		- bins: will display the bounds, count, fraction and cumulative fraction of each bin (default)
+		- quantiles: will display the length quantiles given with --quantiles (5th percentile, median and 95th percentile by default)
+		- chart: will draw the histogram as a bar chart followed by the quantiles, for display in a terminal,
+		  only available with the text format`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if histBins <= 0 {
+			return errors.New("the number of bins must be > 0")
+		}
+		if histMode == "chart" && outputFormat != "text" {
+			return fmt.Errorf("the chart mode can only be written in the text format, got %s", outputFormat)
+		}
+		for _, q := range histQuantiles {
+			if q < 0 || q > 100 {
+				return fmt.Errorf("quantiles must be in [0, 100], got %v", q)
+			}
+		}
+
+		lengths, err := collectLengths(newReader())
+		if err != nil {
+			return err
+		}
+
+		switch histMode {
+		case "bins":
+			return writeHistogram(lengths, histogramEdges(lengths, histBins, histLog))
+		case "quantiles":
+			return writeQuantiles(lengths, histQuantiles)
+		case "chart":
+			return drawHistogram(lengths, histogramEdges(lengths, histBins, histLog), histQuantiles)
+		default:
+			return fmt.Errorf(
+				"mode %s not recognized.\n"+
+					"The mode must be one of the following values: "+
+					"'bins' 'quantiles' 'chart'", histMode)
+		}
+	},
+}
+
+// init adds command to stats and deals with flags
+func init() {
+	statsCmd.AddCommand(histCmd)
+	histCmd.Flags().StringVarP(&histMode, "mode", "m", "bins", "How to display the distribution")
+	histCmd.Flags().IntVarP(&histBins, "bins", "b", 20, "Number of bins")
+	histCmd.Flags().BoolVar(&histLog, "log", false, "Use bins of equal width on a log scale")
+	histCmd.Flags().Float64SliceVarP(&histQuantiles, "quantiles", "q", []float64{5, 50, 95}, "Comma separated quantiles to report, in percent")
+}
+
+// histogramEdges returns the integer bounds of the histogram bins, shared by all statistics groups.
+// Bin i contains the lengths in [edges[i], edges[i+1]), the last edge is greater than the maximum length.
+func histogramEdges(lengths map[string][]int, bins int, logScale bool) []int {
+	min, max := -1, 0
+	for _, group := range lengths {
+		for _, length := range group {
+			if min < 0 || length < min {
+				min = length
+			}
+			if length > max {
+				max = length
+			}
+		}
+	}
+	if min < 0 {
+		return []int{0, 1}
+	}
+
+	edges := []int{min}
+	if logScale {
+		low, high := math.Log10(math.Max(float64(min), 1)), math.Log10(float64(max+1))
+		for i := 1; i <= bins; i++ {
+			edge := int(math.Ceil(math.Pow(10, low+(high-low)*float64(i)/float64(bins))))
+			if edge > edges[len(edges)-1] {
+				edges = append(edges, edge)
+			}
+		}
+	} else {
+		width := (max - min + bins) / bins
+		for edge := min + width; edge <= max+width; edge += width {
+			edges = append(edges, edge)
+			if edge > max {
+				break
+			}
+		}
+	}
+	if edges[len(edges)-1] <= max {
+		edges = append(edges, max+1)
+	}
+
+	return edges
+}
+
+// binCounts returns the number of lengths in each histogram bin
+func binCounts(lengths []int, edges []int) []int {
+	counts := make([]int, len(edges)-1)
+	for _, length := range lengths {
+		bin := sort.SearchInts(edges, length+1) - 1
+		counts[bin]++
+	}
+	return counts
+}
+
+// writeHistogram writes the count, fraction and cumulative fraction of sequences in each bin to the output stream
+func writeHistogram(lengths map[string][]int, edges []int) error {
+	results := newResultWriter(statsColumns(
+		column{Key: "bin_start", Title: "Bin start"},
+		column{Key: "bin_end", Title: "Bin end"},
+		column{Key: "count", Title: "Count"},
+		column{Key: "fraction", Title: "Fraction"},
+		column{Key: "cumulative_fraction", Title: "Cumulative fraction"},
+	)...)
+	results.precision = 4
+
+	for _, group := range statsGroups() {
+		counts := binCounts(lengths[group], edges)
+		total := float64(len(lengths[group]))
+		cumulative := 0
+		for i, count := range counts {
+			cumulative += count
+			fraction, cumulativeFraction := 0.0, 0.0
+			if total > 0 {
+				fraction, cumulativeFraction = float64(count)/total, float64(cumulative)/total
+			}
+			err := results.Write(statsRow(group, edges[i], edges[i+1], count, fraction, cumulativeFraction)...)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return results.Close()
+}
+
+// quantile returns the q-th percentile of lengths sorted in increasing order, interpolating between ranks
+func quantile(sorted []int, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := q / 100 * float64(len(sorted)-1)
+	low := int(math.Floor(rank))
+	if low+1 >= len(sorted) {
+		return float64(sorted[len(sorted)-1])
+	}
+	return float64(sorted[low]) + (rank-float64(low))*float64(sorted[low+1]-sorted[low])
+}
+
+// writeQuantiles writes the requested length quantiles to the output stream
+func writeQuantiles(lengths map[string][]int, quantiles []float64) error {
+	results := newResultWriter(statsColumns(
+		column{Key: "quantile", Title: "Quantile"},
+		column{Key: "length", Title: "Length"},
+	)...)
+
+	for _, group := range statsGroups() {
+		sorted := append([]int(nil), lengths[group]...)
+		sort.Ints(sorted)
+		for _, q := range quantiles {
+			if err := results.Write(statsRow(group, q, quantile(sorted, q))...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return results.Close()
+}
+
+// drawHistogram draws the histogram of each statistics group as a bar chart, followed by the requested quantiles
+func drawHistogram(lengths map[string][]int, edges []int, quantiles []float64) error {
+	labels := make([]string, len(edges)-1)
+	labelWidth := 0
+	for i := range labels {
+		labels[i] = fmt.Sprintf("[%d, %d)", edges[i], edges[i+1])
+		if len(labels[i]) > labelWidth {
+			labelWidth = len(labels[i])
+		}
+	}
+
+	for _, group := range statsGroups() {
+		if perFile {
+			if _, err := fmt.Fprintf(outputWriter, "%s\n", group); err != nil {
+				return err
+			}
+		}
+
+		counts := binCounts(lengths[group], edges)
+		max := 0
+		for _, count := range counts {
+			if count > max {
+				max = count
+			}
+		}
+
+		for i, count := range counts {
+			bar := 0
+			if max > 0 {
+				bar = int(math.Round(float64(count) / float64(max) * chartWidth))
+			}
+			_, err := fmt.Fprintf(outputWriter, "%-*s | %s %d\n", labelWidth, labels[i], strings.Repeat("#", bar), count)
+			if err != nil {
+				return err
+			}
+		}
+
+		sorted := append([]int(nil), lengths[group]...)
+		sort.Ints(sorted)
+		parts := make([]string, len(quantiles))
+		for i, q := range quantiles {
+			parts[i] = fmt.Sprintf("p%v: %.1f", q, quantile(sorted, q))
+		}
+		if _, err := fmt.Fprintf(outputWriter, "%s\n", strings.Join(parts, "  ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var kmerSize int
+var kmersMode string
+var canonical bool
+var topKmers int
+
+// maxKmerSize is the largest k such that k-mers fit in 64 bits with 2 bits per base
+const maxKmerSize = 32
+
+// kmersCmd represents the kmers command
+var kmersCmd = &cobra.Command{
+	Use:   "kmers",
+	Short: "count k-mers of sequences in fasta file",
+	Long: `This command counts the k-mers of length -k/--kmer-size (up to 32) in all sequences.
+	K-mers containing a character other than A, C, G, T or U are skipped. With --canonical,
+	a k-mer and its reverse complement are counted together under the smallest of the two.
+	set the -m/--mode flag to either:
+		- table: will display the count of each k-mer, in lexicographic order
+		- top: will display the -n/--top most frequent k-mers
+		- hist: will display the number of distinct k-mers seen each number of times,
+		  a k-mer spectrum that can be used to estimate genome sizes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if kmerSize < 1 || kmerSize > maxKmerSize {
+			return fmt.Errorf("k-mer size must be between 1 and %d, got %d", maxKmerSize, kmerSize)
+		}
+
+		var write func(group string, counts map[uint64]int, results *resultWriter) error
+		var columns []column
+
+		switch kmersMode {
+		case "table":
+			write = writeKmerTable
+			columns = []column{{Key: "kmer", Title: "K-mer"}, {Key: "count", Title: "Count"}}
+		case "top":
+			if topKmers < 1 {
+				return fmt.Errorf("number of top k-mers must be > 0, got %d", topKmers)
+			}
+			write = writeTopKmers
+			columns = []column{{Key: "kmer", Title: "K-mer"}, {Key: "count", Title: "Count"}}
+		case "hist":
+			write = writeKmerSpectrum
+			columns = []column{{Key: "multiplicity", Title: "Multiplicity"}, {Key: "kmers", Title: "Distinct k-mers"}}
+		default:
+			return fmt.Errorf(
+				"mode %s not recognized.\n"+
+					"The mode must be one of the following values: "+
+					"'table' 'top' 'hist'", kmersMode)
+		}
+
+		counts, err := countKmers(newReader(), kmerSize, canonical)
+		if err != nil {
+			return err
+		}
+
+		results := newResultWriter(statsColumns(columns...)...)
+		for _, group := range statsGroups() {
+			if err := write(group, counts[group], results); err != nil {
+				return err
+			}
+		}
+		return results.Close()
+	},
+}
+
+// init adds command to stats and deals with flags
+func init() {
+	statsCmd.AddCommand(kmersCmd)
+	kmersCmd.Flags().IntVarP(&kmerSize, "kmer-size", "k", 21, "Length of k-mers")
+	kmersCmd.Flags().StringVarP(&kmersMode, "mode", "m", "table", "How to display k-mer counts")
+	kmersCmd.Flags().BoolVar(&canonical, "canonical", false, "Count k-mers and their reverse complement together")
+	kmersCmd.Flags().IntVarP(&topKmers, "top", "n", 10, "Number of k-mers displayed in the top mode")
+}
+
+// baseCodes are the 2-bit encodings of nucleotides, -1 for other characters
+var baseCodes = func() [256]int8 {
+	var codes [256]int8
+	for i := range codes {
+		codes[i] = -1
+	}
+	for code, chars := range []string{"Aa", "Cc", "Gg", "TtUu"} {
+		for _, char := range []byte(chars) {
+			codes[char] = int8(code)
+		}
+	}
+	return codes
+}()
+
+// codeBases are the nucleotides encoded by each 2-bit code
+const codeBases = "ACGT"
+
+// countKmers counts the k-mers of length k of all sequences in the input stream, for each statistics group.
+// K-mers are encoded on 2 bits per base, so that their numerical order is their lexicographic order.
+// K-mers overlapping a character that is not a nucleotide are skipped.
+func countKmers(reader *seqs.Reader, k int, canonical bool) (map[string]map[uint64]int, error) {
+	counts := make(map[string]map[uint64]int)
+	mask := uint64(1)<<(2*uint(k)) - 1
+	shift := 2 * uint(k-1)
+
+	for reader.Scan() {
+		record := reader.Record()
+		group := statsGroup(record)
+		if counts[group] == nil {
+			counts[group] = make(map[uint64]int)
+		}
+		groupCounts := counts[group]
+
+		var forward, reverse uint64
+		valid := 0
+		for i := 0; i < len(record.Seq); i++ {
+			code := baseCodes[record.Seq[i]]
+			if code < 0 {
+				valid = 0
+				continue
+			}
+			forward = (forward<<2 | uint64(code)) & mask
+			reverse = reverse>>2 | uint64(3-code)<<shift
+			if valid++; valid < k {
+				continue
+			}
+			if canonical && reverse < forward {
+				groupCounts[reverse]++
+			} else {
+				groupCounts[forward]++
+			}
+		}
+	}
+
+	return counts, reader.Err()
+}
+
+// decodeKmer returns the nucleotide string of a 2-bit encoded k-mer of length k
+func decodeKmer(kmer uint64, k int) string {
+	bases := make([]byte, k)
+	for i := k - 1; i >= 0; i-- {
+		bases[i] = codeBases[kmer&3]
+		kmer >>= 2
+	}
+	return string(bases)
+}
+
+// writeKmerTable writes the count of each k-mer in lexicographic order
+func writeKmerTable(group string, counts map[uint64]int, results *resultWriter) error {
+	kmers := make([]uint64, 0, len(counts))
+	for kmer := range counts {
+		kmers = append(kmers, kmer)
+	}
+	sort.Slice(kmers, func(i, j int) bool { return kmers[i] < kmers[j] })
+
+	for _, kmer := range kmers {
+		if err := results.Write(statsRow(group, decodeKmer(kmer, kmerSize), counts[kmer])...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTopKmers writes the most frequent k-mers by decreasing count, ties being broken lexicographically
+func writeTopKmers(group string, counts map[uint64]int, results *resultWriter) error {
+	kmers := make([]uint64, 0, len(counts))
+	for kmer := range counts {
+		kmers = append(kmers, kmer)
+	}
+	sort.Slice(kmers, func(i, j int) bool {
+		if counts[kmers[i]] != counts[kmers[j]] {
+			return counts[kmers[i]] > counts[kmers[j]]
+		}
+		return kmers[i] < kmers[j]
+	})
+	if len(kmers) > topKmers {
+		kmers = kmers[:topKmers]
+	}
+
+	for _, kmer := range kmers {
+		if err := results.Write(statsRow(group, decodeKmer(kmer, kmerSize), counts[kmer])...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeKmerSpectrum writes the number of distinct k-mers seen each number of times, by increasing multiplicity
+func writeKmerSpectrum(group string, counts map[uint64]int, results *resultWriter) error {
+	spectrum := make(map[int]int)
+	for _, count := range counts {
+		spectrum[count]++
+	}
+	multiplicities := make([]int, 0, len(spectrum))
+	for multiplicity := range spectrum {
+		multiplicities = append(multiplicities, multiplicity)
+	}
+	sort.Ints(multiplicities)
+
+	for _, multiplicity := range multiplicities {
+		if err := results.Write(statsRow(group, multiplicity, spectrum[multiplicity])...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,13 +17,15 @@ package cmd
 
 import (
 	"fmt"
-	"io"
+	"sort"
 
 	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
 )
 
 var lengthMode string
+var nxValues []float64
+var genomeSize int
 
 // lengthCmd represents the length command
 var lengthCmd = &cobra.Command{
@@ -35,36 +37,44 @@ var lengthCmd = &cobra.Command{
 		- each: will display the length of each sequence
 		- average (or mean) will display the average lengh
 		- min (or minimum) will display the minimum length
-		- max (or maximum) will display the maximum length`,
+		- max (or maximum) will display the maximum length
+		- n50 will display the N50 and L50
+		- nx will display the Nx and Lx for each x given with --nx
+		- aun will display the area under the Nx curve (auN)
+	When an expected genome size is given with -g/--genome-size, the n50, nx and aun
+	modes also display the NGx, LGx and auNG computed against this size.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		records := make(chan seqs.SeqRecord)
-		errs := make(chan error)
-
-		go seqs.ReadFastaRecords(inputReader, records, errs)
+		reader := newReader()
 
 		var err error
 
 		switch lengthMode {
 		case "each":
-			err = getEach(records, errs, outputWriter)
+			err = getEach(reader)
 		case "average":
-			err = getAverage(records, errs, outputWriter)
+			err = getAverage(reader)
 		case "mean":
-			err = getAverage(records, errs, outputWriter)
+			err = getAverage(reader)
 		case "min":
-			err = getMin(records, errs, outputWriter)
+			err = getMin(reader)
 		case "minimum":
-			err = getMin(records, errs, outputWriter)
+			err = getMin(reader)
 		case "max":
-			err = getMax(records, errs, outputWriter)
+			err = getMax(reader)
 		case "maximum":
-			err = getMax(records, errs, outputWriter)
+			err = getMax(reader)
+		case "n50":
+			err = getNx(reader, []float64{50})
+		case "nx":
+			err = getNx(reader, nxValues)
+		case "aun":
+			err = getAuN(reader)
 		default:
 			return fmt.Errorf(
 				"mode %s not recognized.\n"+
 					"The mode must be one of the following values: "+
-					"'each' 'average' 'mean' 'min' 'minimum' 'max' 'maximum'", lengthMode)
+					"'each' 'average' 'mean' 'min' 'minimum' 'max' 'maximum' 'n50' 'nx' 'aun'", lengthMode)
 		}
 		return err
 	},
@@ -74,84 +84,245 @@ var lengthCmd = &cobra.Command{
 func init() {
 	statsCmd.AddCommand(lengthCmd)
 	lengthCmd.Flags().StringVarP(&lengthMode, "mode", "m", "each", "How to display lengths")
+	lengthCmd.Flags().Float64SliceVar(&nxValues, "nx", []float64{50}, "Comma separated x values for the nx mode (e.g. 10,50,90)")
+	lengthCmd.Flags().IntVarP(&genomeSize, "genome-size", "g", 0, "Expected genome size, used to compute NGx, LGx and auNG")
+}
+
+// lengthColumn is the column of a single length statistic in the output
+func lengthColumn(key string, title string) []column {
+	return statsColumns(column{Key: key, Title: title})
 }
 
 // getEach writes the length of each sequence in the input stream to the output stream
-func getEach(records chan seqs.SeqRecord, errs chan error, output io.Writer) error {
+func getEach(reader *seqs.Reader) error {
+	results := newResultWriter(statsColumns(column{Key: "name", Title: "Name"}, column{Key: "length", Title: "Length"})...)
 
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			_, err := fmt.Fprintf(output, "%s\t%d\n", record.Name, record.Seq.Length())
-			if err != nil {
-				return err
-			}
-		case err := <-errs:
+	for reader.Scan() {
+		record := reader.Record()
+		if err := results.Write(statsRow(record.Source, recordKey(record), record.Seq.Length())...); err != nil {
 			return err
 		}
 	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
 
-	return nil
+	return results.Close()
 }
 
 // getAverage writes the average length of sequences to the output stream
-func getAverage(records chan seqs.SeqRecord, errs chan error, output io.Writer) error {
-	total, count := 0, 0
-
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			total += record.Seq.Length()
-			count++
-		case err := <-errs:
-			if err != nil {
-				return err
-			}
-			_, err = fmt.Fprintln(output, float32(total)/float32(count))
+func getAverage(reader *seqs.Reader) error {
+	totals, counts := make(map[string]int), make(map[string]int)
+
+	for reader.Scan() {
+		record := reader.Record()
+		group := statsGroup(record)
+		totals[group] += record.Seq.Length()
+		counts[group]++
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	results := newResultWriter(lengthColumn("mean", "Mean length")...)
+	results.precision = 2
+	for _, group := range statsGroups() {
+		if err := results.Write(statsRow(group, float64(totals[group])/float64(counts[group]))...); err != nil {
 			return err
 		}
 	}
-	return nil
+	return results.Close()
 }
 
 // getMin writes the length of the shortest sequence to the output stream
-func getMin(records chan seqs.SeqRecord, errs chan error, output io.Writer) error {
-	min := -1
-
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			if min < 0 || record.Seq.Length() < min {
-				min = record.Seq.Length()
-			}
-		case err := <-errs:
-			if err != nil {
-				return err
-			}
-			_, err = fmt.Fprintln(output, min)
+func getMin(reader *seqs.Reader) error {
+	mins := make(map[string]int)
+
+	for reader.Scan() {
+		record := reader.Record()
+		group := statsGroup(record)
+		if min, ok := mins[group]; !ok || record.Seq.Length() < min {
+			mins[group] = record.Seq.Length()
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	results := newResultWriter(lengthColumn("min", "Minimum length")...)
+	for _, group := range statsGroups() {
+		min, ok := mins[group]
+		if !ok {
+			min = -1
+		}
+		if err := results.Write(statsRow(group, min)...); err != nil {
 			return err
 		}
 	}
-	return nil
+	return results.Close()
 }
 
 // getMax writes the length of the longest sequence to the output stream
-func getMax(records chan seqs.SeqRecord, errs chan error, output io.Writer) error {
-	max := -1
-
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			if max < 0 || record.Seq.Length() > max {
-				max = record.Seq.Length()
+func getMax(reader *seqs.Reader) error {
+	maxs := make(map[string]int)
+
+	for reader.Scan() {
+		record := reader.Record()
+		group := statsGroup(record)
+		if max, ok := maxs[group]; !ok || record.Seq.Length() > max {
+			maxs[group] = record.Seq.Length()
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	results := newResultWriter(lengthColumn("max", "Maximum length")...)
+	for _, group := range statsGroups() {
+		max, ok := maxs[group]
+		if !ok {
+			max = -1
+		}
+		if err := results.Write(statsRow(group, max)...); err != nil {
+			return err
+		}
+	}
+	return results.Close()
+}
+
+// collectLengths reads the length of all sequences in the input stream, for each statistics group
+func collectLengths(reader *seqs.Reader) (map[string][]int, error) {
+	lengths := make(map[string][]int)
+	for reader.Scan() {
+		record := reader.Record()
+		group := statsGroup(record)
+		lengths[group] = append(lengths[group], record.Seq.Length())
+	}
+	return lengths, reader.Err()
+}
+
+// getNx writes the Nx and Lx values of the sequences to the output stream for each x,
+// as well as the NGx and LGx values if a genome size was given
+func getNx(reader *seqs.Reader, xs []float64) error {
+	for _, x := range xs {
+		if x <= 0 || x > 100 {
+			return fmt.Errorf("nx values must be in ]0, 100], got %v", x)
+		}
+	}
+
+	lengths, err := collectLengths(reader)
+	if err != nil {
+		return err
+	}
+
+	columns := []column{{Key: "x", Title: "x"}, {Key: "nx", Title: "Nx"}, {Key: "lx", Title: "Lx"}}
+	if genomeSize > 0 {
+		columns = append(columns, column{Key: "ngx", Title: "NGx"}, column{Key: "lgx", Title: "LGx"})
+	}
+	results := newResultWriter(statsColumns(columns...)...)
+
+	for _, group := range statsGroups() {
+		sorted := sortedLengths(lengths[group])
+		total := 0
+		for _, length := range sorted {
+			total += length
+		}
+
+		for _, x := range xs {
+			n, l := contiguity(sorted, total, x)
+			values := []interface{}{x, n, l}
+			if genomeSize > 0 {
+				ng, lg := contiguity(sorted, genomeSize, x)
+				values = append(values, ng, lg)
 			}
-		case err := <-errs:
-			if err != nil {
+			if err := results.Write(statsRow(group, values...)...); err != nil {
 				return err
 			}
-			_, err = fmt.Fprintln(output, max)
+		}
+	}
+
+	return results.Close()
+}
+
+// getAuN writes the area under the Nx curve of the sequences to the output stream,
+// as well as the area under the NGx curve if a genome size was given
+func getAuN(reader *seqs.Reader) error {
+	lengths, err := collectLengths(reader)
+	if err != nil {
+		return err
+	}
+
+	columns := []column{{Key: "aun", Title: "auN"}}
+	if genomeSize > 0 {
+		columns = append(columns, column{Key: "aung", Title: "auNG"})
+	}
+	results := newResultWriter(statsColumns(columns...)...)
+	results.precision = 2
+
+	for _, group := range statsGroups() {
+		total := 0
+		for _, length := range lengths[group] {
+			total += length
+		}
+
+		values := []interface{}{areaUnderNx(lengths[group], total)}
+		if genomeSize > 0 {
+			values = append(values, areaUnderNx(lengths[group], genomeSize))
+		}
+		if err := results.Write(statsRow(group, values...)...); err != nil {
 			return err
 		}
 	}
-	return nil
+
+	return results.Close()
+}
+
+// sortedLengths returns a copy of the lengths sorted in decreasing order
+func sortedLengths(lengths []int) []int {
+	sorted := make([]int, len(lengths))
+	copy(sorted, lengths)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	return sorted
+}
+
+// contiguity returns the Nx and Lx values of sequence lengths sorted in decreasing order:
+// Nx is the length of the shortest sequence such that sequences at least this long cover x% of
+// the `size`, Lx is the number of such sequences. `size` is the total length of the sequences,
+// or an expected genome size for NGx and LGx. If the sequences cover less than x% of the size,
+// both values are 0.
+func contiguity(sorted []int, size int, x float64) (int, int) {
+	target := float64(size) * x / 100
+	cumulative := 0
+	for i, length := range sorted {
+		cumulative += length
+		if float64(cumulative) >= target {
+			return length, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// areaUnderNx returns the auN of sequence lengths: the sum of squared lengths divided by
+// `size`, the total length of the sequences or an expected genome size
+func areaUnderNx(lengths []int, size int) float64 {
+	if size == 0 {
+		return 0
+	}
+	var squares float64
+	for _, length := range lengths {
+		squares += float64(length) * float64(length)
+	}
+	return squares / float64(size)
+}
+
+// median returns the median of lengths sorted in any monotonic order
+func median(sorted []int) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return float64(sorted[n/2])
+	}
+	return float64(sorted[n/2-1]+sorted[n/2]) / 2
 }
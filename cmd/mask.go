@@ -0,0 +1,262 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var maskMode string
+var maskBedFile string
+var maskInvert bool
+var maskChar string
+
+// maskCmd represents the mask command
+var maskCmd = &cobra.Command{
+	Use:   "mask",
+	Short: "Mask regions of sequences",
+	Long: `This command masks regions of sequences, which are by default the soft-masked (lowercase) bases
+	of each sequence. With -b/--bed, the regions are instead read from a BED file, in which the first column
+	is the sequence name. With --invert, everything outside of the regions is masked instead.
+	set the -m/--mode flag to either:
+		- hard: will replace the bases of the regions by --char (N by default)
+		- soft: will lowercase the bases of the regions
+		- unmask: will uppercase the bases of the regions
+		- bed: will output the regions of each sequence as BED intervals instead of sequences
+	By default lowercase bases are hard-masked.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(maskChar) != 1 {
+			return errors.New("the masking character must be a single character")
+		}
+
+		var bed map[string][]interval
+		if maskBedFile != "" {
+			var err error
+			if bed, err = readBed(maskBedFile); err != nil {
+				return err
+			}
+		}
+
+		regions := func(record seqs.SeqRecord) []interval {
+			var intervals []interval
+			if bed != nil {
+				intervals = clipIntervals(bed[recordKey(record)], record.Seq.Length())
+			} else {
+				intervals = softMaskedIntervals(record.Seq)
+			}
+			if maskInvert {
+				intervals = invertIntervals(intervals, record.Seq.Length())
+			}
+			return intervals
+		}
+
+		var mask func(char byte) byte
+		switch maskMode {
+		case "hard":
+			mask = func(char byte) byte { return maskChar[0] }
+		case "soft":
+			mask = func(char byte) byte {
+				if 'A' <= char && char <= 'Z' {
+					return char + 'a' - 'A'
+				}
+				return char
+			}
+		case "unmask":
+			mask = func(char byte) byte {
+				if 'a' <= char && char <= 'z' {
+					return char - ('a' - 'A')
+				}
+				return char
+			}
+		case "bed":
+			return writeMaskedIntervals(regions)
+		default:
+			return fmt.Errorf(
+				"mode %s not recognized.\n"+
+					"The mode must be one of the following values: "+
+					"'hard' 'soft' 'unmask' 'bed'", maskMode)
+		}
+
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			masked := []byte(record.Seq)
+			for _, region := range regions(*record) {
+				for i := region.start; i < region.end; i++ {
+					masked[i] = mask(masked[i])
+				}
+			}
+			record.Seq = seqs.Seq(masked)
+			return nil
+		})
+	},
+}
+
+// init adds the command to transform and deals with flags
+func init() {
+	transformCmd.AddCommand(maskCmd)
+	maskCmd.Flags().StringVarP(&maskMode, "mode", "m", "hard", "How to mask regions")
+	maskCmd.Flags().StringVarP(&maskBedFile, "bed", "b", "", "BED file of the regions to mask (default is the soft-masked bases)")
+	maskCmd.Flags().BoolVar(&maskInvert, "invert", false, "Mask everything outside of the regions")
+	maskCmd.Flags().StringVar(&maskChar, "char", "N", "Character used for hard masking")
+}
+
+// interval is a region of a sequence, with 0-based start and exclusive end coordinates like in BED files
+type interval struct {
+	start int
+	end   int
+}
+
+// readBed reads the intervals of a BED file, which can be compressed, for each sequence name.
+// Its compression is always detected from its first bytes, the -c flag only applies to sequence inputs.
+// The intervals of each sequence are sorted and overlapping intervals are merged.
+func readBed(name string) (map[string][]interval, error) {
+	file, err := openCompressed(name, "")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	bed := make(map[string][]interval)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1<<16), 1<<24)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "track") || strings.HasPrefix(line, "browser") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%s: line %d: expected at least 3 columns", name, lineNum)
+		}
+		start, err := strconv.Atoi(fields[1])
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("%s: line %d: invalid start %q", name, lineNum, fields[1])
+		}
+		end, err := strconv.Atoi(fields[2])
+		if err != nil || end < start {
+			return nil, fmt.Errorf("%s: line %d: invalid end %q", name, lineNum, fields[2])
+		}
+		bed[fields[0]] = append(bed[fields[0]], interval{start, end})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	for name, intervals := range bed {
+		bed[name] = mergeIntervals(intervals)
+	}
+	return bed, nil
+}
+
+// mergeIntervals sorts intervals and merges the overlapping or adjacent ones
+func mergeIntervals(intervals []interval) []interval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	var merged []interval
+	for _, current := range intervals {
+		if last := len(merged) - 1; last >= 0 && current.start <= merged[last].end {
+			if current.end > merged[last].end {
+				merged[last].end = current.end
+			}
+			continue
+		}
+		merged = append(merged, current)
+	}
+	return merged
+}
+
+// clipIntervals returns the parts of sorted intervals that are within a sequence of the given length
+func clipIntervals(intervals []interval, length int) []interval {
+	var clipped []interval
+	for _, current := range intervals {
+		if current.start >= length {
+			break
+		}
+		if current.end > length {
+			current.end = length
+		}
+		if current.start < current.end {
+			clipped = append(clipped, current)
+		}
+	}
+	return clipped
+}
+
+// invertIntervals returns the intervals of a sequence of the given length that are not covered by sorted intervals
+func invertIntervals(intervals []interval, length int) []interval {
+	var inverted []interval
+	start := 0
+	for _, current := range intervals {
+		if current.start > start {
+			inverted = append(inverted, interval{start, current.start})
+		}
+		start = current.end
+	}
+	if start < length {
+		inverted = append(inverted, interval{start, length})
+	}
+	return inverted
+}
+
+// softMaskedIntervals returns the runs of lowercase characters of a sequence
+func softMaskedIntervals(seq seqs.Seq) []interval {
+	var intervals []interval
+	start := -1
+	for i := 0; i <= len(seq); i++ {
+		lower := i < len(seq) && 'a' <= seq[i] && seq[i] <= 'z'
+		switch {
+		case lower && start < 0:
+			start = i
+		case !lower && start >= 0:
+			intervals = append(intervals, interval{start, i})
+			start = -1
+		}
+	}
+	return intervals
+}
+
+// writeMaskedIntervals writes the regions of each sequence of the input stream as BED intervals
+func writeMaskedIntervals(regions func(record seqs.SeqRecord) []interval) error {
+	reader := newReader()
+	results := newResultWriter(
+		column{Key: "name", Title: "Name"},
+		column{Key: "start", Title: "Start"},
+		column{Key: "end", Title: "End"},
+	)
+
+	for reader.Scan() {
+		record := reader.Record()
+		for _, region := range regions(record) {
+			if err := results.Write(recordKey(record), region.start, region.end); err != nil {
+				return err
+			}
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return results.Close()
+}
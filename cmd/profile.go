@@ -0,0 +1,170 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var profileCounts bool
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "get per-column frequencies of aligned sequences in fasta file",
+	Long: `This command reads aligned sequences, which must all have the same length, and outputs for each
+	column of the alignment the frequency of each symbol, the Shannon entropy of the column in bits and
+	its fraction of gaps ('-' or '.'). The entropy is computed over the symbols that are not gaps.
+	Characters are uppercased, and --counts displays the number of occurrences of each symbol instead
+	of its frequency. Sequences are read one at a time, only the counts are kept in memory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profiles, err := buildProfiles(newReader())
+		if err != nil {
+			return err
+		}
+
+		groups := statsGroups()
+		symbols := make(map[byte]bool)
+		for _, group := range groups {
+			if profiles[group] != nil {
+				for symbol := range profiles[group].counts {
+					symbols[symbol] = true
+				}
+			}
+		}
+
+		columnSymbols := make([]byte, 0, len(symbols))
+		for symbol := range symbols {
+			columnSymbols = append(columnSymbols, symbol)
+		}
+		sort.Slice(columnSymbols, func(i, j int) bool { return columnSymbols[i] < columnSymbols[j] })
+
+		columns := []column{{Key: "position", Title: "Position"}}
+		for _, symbol := range columnSymbols {
+			columns = append(columns, column{Key: string(symbol), Title: string(symbol)})
+		}
+		columns = append(columns, column{Key: "entropy", Title: "Entropy"}, column{Key: "gap_fraction", Title: "Gap fraction"})
+		results := newResultWriter(statsColumns(columns...)...)
+		results.precision = 4
+		results.header = true
+
+		for _, group := range groups {
+			profile := profiles[group]
+			if profile == nil {
+				continue
+			}
+			for position := 0; position < profile.length; position++ {
+				if err := results.Write(statsRow(group, profile.values(position, columnSymbols)...)...); err != nil {
+					return err
+				}
+			}
+		}
+
+		return results.Close()
+	},
+}
+
+// init adds command to stats and deals with flags
+func init() {
+	statsCmd.AddCommand(profileCmd)
+	profileCmd.Flags().BoolVar(&profileCounts, "counts", false, "Display the number of occurrences instead of frequencies")
+}
+
+// alignmentProfile holds the number of occurrences of each symbol at each column of an alignment
+type alignmentProfile struct {
+	length    int
+	sequences int
+	counts    map[byte][]int
+}
+
+// add counts the symbols of an aligned sequence, symbols being uppercased
+func (profile *alignmentProfile) add(seq seqs.Seq) {
+	for i := 0; i < len(seq); i++ {
+		symbol := seq[i]
+		if 'a' <= symbol && symbol <= 'z' {
+			symbol -= 'a' - 'A'
+		}
+		if profile.counts[symbol] == nil {
+			profile.counts[symbol] = make([]int, profile.length)
+		}
+		profile.counts[symbol][i]++
+	}
+	profile.sequences++
+}
+
+// values returns the row of a column of the alignment: its 1-based position, the count or frequency
+// of each of the symbols, its entropy and its gap fraction. `symbols` must contain all the symbols of the profile.
+func (profile *alignmentProfile) values(position int, symbols []byte) []interface{} {
+	values := []interface{}{position + 1}
+	gaps, residues := 0, 0
+	for _, symbol := range symbols {
+		count := 0
+		if profile.counts[symbol] != nil {
+			count = profile.counts[symbol][position]
+		}
+		if symbol == '-' || symbol == '.' {
+			gaps += count
+		} else {
+			residues += count
+		}
+
+		if profileCounts {
+			values = append(values, count)
+		} else {
+			values = append(values, float64(count)/float64(profile.sequences))
+		}
+	}
+
+	entropy := 0.0
+	for _, symbol := range symbols {
+		if symbol == '-' || symbol == '.' || profile.counts[symbol] == nil || profile.counts[symbol][position] == 0 {
+			continue
+		}
+		p := float64(profile.counts[symbol][position]) / float64(residues)
+		entropy -= p * math.Log2(p)
+	}
+
+	return append(values, entropy, float64(gaps)/float64(profile.sequences))
+}
+
+// buildProfiles reads the aligned sequences of the input stream and counts their symbols for each
+// statistics group, checking that all sequences of a group have the same length
+func buildProfiles(reader *seqs.Reader) (map[string]*alignmentProfile, error) {
+	profiles := make(map[string]*alignmentProfile)
+
+	for reader.Scan() {
+		record := reader.Record()
+		group := statsGroup(record)
+		profile := profiles[group]
+		if profile == nil {
+			profile = &alignmentProfile{length: record.Seq.Length(), counts: make(map[byte][]int)}
+			profiles[group] = profile
+		}
+		if record.Seq.Length() != profile.length {
+			return nil, fmt.Errorf(
+				"sequence %s has length %d but previous sequences have length %d, input must be aligned",
+				recordKey(record), record.Seq.Length(), profile.length)
+		}
+		profile.add(record.Seq)
+	}
+
+	return profiles, reader.Err()
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lucblassel/fastago/pkg/protein"
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+// proteinCmd represents the protein command
+var proteinCmd = &cobra.Command{
+	Use:   "protein",
+	Short: "get physico-chemical properties of protein sequences in fasta file",
+	Long: `This command outputs, for each protein sequence, its length, average molecular weight in Daltons,
+	isoelectric point, grand average of hydropathy (GRAVY), instability index, aromaticity and molar
+	extinction coefficients at 280 nm, assuming all cysteines form cystines or are reduced.
+	Sequences may only contain the 20 standard amino acids, the ambiguity codes B, Z, J and X,
+	selenocysteine (U), pyrrolysine (O), stops and gaps, which are ignored.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reader := newReader()
+		return getProteinProperties(reader)
+	},
+}
+
+// init adds command to stats and deals with flags
+func init() {
+	statsCmd.AddCommand(proteinCmd)
+}
+
+// getProteinProperties writes the properties of each protein sequence to the output stream
+func getProteinProperties(reader *seqs.Reader) error {
+	results := newResultWriter(statsColumns(
+		column{Key: "name", Title: "Name"},
+		column{Key: "length", Title: "Length"},
+		column{Key: "molecular_weight", Title: "Molecular weight (Da)"},
+		column{Key: "isoelectric_point", Title: "Isoelectric point"},
+		column{Key: "gravy", Title: "GRAVY"},
+		column{Key: "instability_index", Title: "Instability index"},
+		column{Key: "aromaticity", Title: "Aromaticity"},
+		column{Key: "extinction_coefficient", Title: "Extinction coefficient (cystines)"},
+		column{Key: "extinction_coefficient_reduced", Title: "Extinction coefficient (reduced)"},
+	)...)
+	results.precision = 4
+
+	for reader.Scan() {
+		record := reader.Record()
+		for i := 0; i < len(record.Seq); i++ {
+			if !seqs.Protein.Contains(record.Seq[i]) {
+				return fmt.Errorf("sequence %s contains %q at position %d, which is not an amino acid",
+					recordKey(record), record.Seq[i], i+1)
+			}
+		}
+
+		properties := protein.Analyze(string(record.Seq))
+		err := results.Write(statsRow(record.Source,
+			recordKey(record), properties.Length,
+			properties.MolecularWeight, properties.IsoelectricPoint, properties.Gravy,
+			properties.InstabilityIndex, properties.Aromaticity,
+			properties.ExtinctionCoefficient, properties.ExtinctionCoefficientReduced,
+		)...)
+		if err != nil {
+			return err
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return results.Close()
+}
@@ -0,0 +1,180 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var degenerateScheme string
+
+// transcribeCmd represents the transcribe command
+var transcribeCmd = &cobra.Command{
+	Use:   "transcribe",
+	Short: "Transcribe DNA sequences to RNA, replacing T with U",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			record.Seq = record.Seq.Transcribe()
+			return nil
+		})
+	},
+}
+
+// backTranscribeCmd represents the back-transcribe command
+var backTranscribeCmd = &cobra.Command{
+	Use:   "back-transcribe",
+	Short: "Back-transcribe RNA sequences to DNA, replacing U with T",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			record.Seq = record.Seq.BackTranscribe()
+			return nil
+		})
+	},
+}
+
+// degenerateCmd represents the degenerate command
+var degenerateCmd = &cobra.Command{
+	Use:   "degenerate",
+	Short: "Recode nucleotides into two IUPAC classes",
+	Long: `This command recodes nucleotides into the two IUPAC classes of a binary scheme, as used
+	in phylogenetics to reduce compositional bias. The scheme is selected with -s/--scheme:
+		- ry: purines (A, G) become R and pyrimidines (C, T, U) become Y
+		- sw: strong bases (C, G) become S and weak bases (A, T, U) become W
+		- km: keto bases (G, T, U) become K and amino bases (A, C) become M
+	Ambiguity codes are recoded if all the bases they stand for are in the same class, and become N
+	otherwise. Case is kept and other characters such as gaps are left unchanged.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		table, err := degenerateTable(degenerateScheme)
+		if err != nil {
+			return err
+		}
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			record.Seq = table.recode(record.Seq)
+			return nil
+		})
+	},
+}
+
+// dayhoffCmd represents the dayhoff command
+var dayhoffCmd = &cobra.Command{
+	Use:   "dayhoff",
+	Short: "Recode protein sequences into the 6 Dayhoff classes",
+	Long: `This command recodes amino acids into the 6 Dayhoff classes of physico-chemically similar residues:
+		- A: A, G, P, S, T
+		- B: D, E, N, Q
+		- C: H, K, R
+		- D: I, L, M, V
+		- E: F, W, Y
+		- F: C
+	The ambiguity codes B and Z become B and J becomes D, other residues become X.
+	Case is kept and stops and gaps are left unchanged.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			record.Seq = dayhoffTable.recode(record.Seq)
+			return nil
+		})
+	},
+}
+
+// init adds the commands to transform and deals with flags
+func init() {
+	transformCmd.AddCommand(transcribeCmd)
+	transformCmd.AddCommand(backTranscribeCmd)
+	transformCmd.AddCommand(degenerateCmd)
+	transformCmd.AddCommand(dayhoffCmd)
+	degenerateCmd.Flags().StringVarP(&degenerateScheme, "scheme", "s", "ry", "Recoding scheme [ry, sw, km]")
+}
+
+// recodeTable maps each character to its recoded character
+type recodeTable [256]byte
+
+// newRecodeTable returns a table recoding each character of the keys of `classes` to the class it maps to,
+// in upper and lower case. Other characters are left unchanged.
+func newRecodeTable(classes map[string]byte) *recodeTable {
+	var table recodeTable
+	for i := range table {
+		table[i] = byte(i)
+	}
+	for chars, class := range classes {
+		for _, char := range []byte(strings.ToUpper(chars)) {
+			table[char] = class
+			table[char+'a'-'A'] = class + 'a' - 'A'
+		}
+	}
+	return &table
+}
+
+// recode returns the sequence with each character recoded
+func (table *recodeTable) recode(seq seqs.Seq) seqs.Seq {
+	recoded := make([]byte, len(seq))
+	for i := 0; i < len(seq); i++ {
+		recoded[i] = table[seq[i]]
+	}
+	return seqs.Seq(recoded)
+}
+
+// iupacBases are the nucleotides each IUPAC code stands for
+var iupacBases = map[byte]string{
+	'A': "A", 'C': "C", 'G': "G", 'T': "T", 'U': "T",
+	'R': "AG", 'Y': "CT", 'S': "CG", 'W': "AT", 'K': "GT", 'M': "AC",
+	'B': "CGT", 'D': "AGT", 'H': "ACT", 'V': "ACG", 'N': "ACGT",
+}
+
+// degenerateSchemes are the two classes of each binary recoding scheme, as the IUPAC code
+// of each class and the bases it contains
+var degenerateSchemes = map[string][2]struct {
+	code  byte
+	bases string
+}{
+	"ry": {{'R', "AG"}, {'Y', "CT"}},
+	"sw": {{'S', "CG"}, {'W', "AT"}},
+	"km": {{'K', "GT"}, {'M', "AC"}},
+}
+
+// degenerateTable returns the table recoding nucleotides with a binary scheme. IUPAC codes standing
+// for bases of both classes become N.
+func degenerateTable(scheme string) (*recodeTable, error) {
+	classes, ok := degenerateSchemes[scheme]
+	if !ok {
+		return nil, fmt.Errorf("scheme %s not recognized, must be one of 'ry' 'sw' 'km'", scheme)
+	}
+
+	recoding := make(map[string]byte)
+	for code, bases := range iupacBases {
+		recoding[string(code)] = 'N'
+		for _, class := range classes {
+			if strings.Trim(bases, class.bases) == "" {
+				recoding[string(code)] = class.code
+			}
+		}
+	}
+	return newRecodeTable(recoding), nil
+}
+
+// dayhoffTable recodes amino acids into the 6 Dayhoff classes
+var dayhoffTable = newRecodeTable(map[string]byte{
+	"AGPST":  'A',
+	"DENQBZ": 'B',
+	"HKR":    'C',
+	"ILMVJ":  'D',
+	"FWY":    'E',
+	"C":      'F',
+	"XUO":    'X',
+})
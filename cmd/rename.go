@@ -18,8 +18,6 @@ package cmd
 import (
 	"bufio"
 	"errors"
-	"fmt"
-	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
 	"os"
 	"regexp"
@@ -95,29 +93,24 @@ func readMap(filename string) (map[string]string, error) {
 // renameFromMap prints the sequences renamed from a map file to the output stream
 func renameFromMap(renamer map[string]string) error {
 
-	records := make(chan seqs.SeqRecord)
-	errs := make(chan error)
+	reader := newReader()
+	writer := newWriter(reader.Format())
 
-	go seqs.ReadFastaRecords(inputReader, records, errs)
-
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			newName := record.Name
-			if val, ok := renamer[newName]; ok {
-				newName = val
-			}
-			output, err := record.Seq.FormatSeq(outputLineWidth)
-			_, err = fmt.Fprintf(outputWriter, ">%s\n%s\n", newName, output)
-			if err != nil {
-				return err
-			}
-		case err := <-errs:
+	for reader.Scan() {
+		record := reader.Record()
+		if val, ok := renamer[recordKey(record)]; ok {
+			setRecordKey(&record, val)
+		}
+		if err := writer.Write(record); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return writer.Flush()
 }
 
 // renameFromRegex prints the sequences renamed with a regular expression and repalcement group to the output stream
@@ -127,28 +120,21 @@ func renameFromRegex(expression string, replace string) error {
 		return err
 	}
 
-	records := make(chan seqs.SeqRecord)
-	errs := make(chan error)
-
-	go seqs.ReadFastaRecords(inputReader, records, errs)
+	reader := newReader()
+	writer := newWriter(reader.Format())
 
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			newName := regex.ReplaceAllString(record.Name, replace)
-			output, err := record.Seq.FormatSeq(outputLineWidth)
-			if err != nil {
-				return err
-			}
-			_, err = fmt.Fprintf(outputWriter, ">%s\n%s\n", newName, output)
-			if err != nil {
-				return err
-			}
-		case err := <-errs:
+	for reader.Scan() {
+		record := reader.Record()
+		setRecordKey(&record, regex.ReplaceAllString(recordKey(record), replace))
+		if err := writer.Write(record); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return writer.Flush()
 
 }
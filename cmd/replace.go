@@ -17,47 +17,179 @@ package cmd
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
 )
 
+var replaceMode string
+var replaceIDRegex string
+
 // replaceCmd represents the replace command
 var replaceCmd = &cobra.Command{
-	Use:   "replace [char1] [char2]",
+	Use:   "replace [pattern] [replacement]",
 	Short: "replace a given character in all sequences by another",
-	Long: `This command replaces the character given as first argument 
-	with the character given as second argument.`,
-	Args: cobra.ExactArgs(2),
+	Long: `This command replaces the pattern given as first argument 
+	with the replacement given as second argument, in the raw sequences.
+	set the -m/--mode flag to either:
+		- string: will replace all occurrences of the pattern string
+		- tr: will replace each character of the first set by the character at the same position
+		  in the second set, like the tr utility. The last character of the second set is repeated
+		  if it is shorter, and characters of the first set are deleted if it is empty.
+		  Sets can contain ranges (a-z) and the classes [:lower:], [:upper:], [:alpha:], [:digit:],
+		  [:gap:] ('-' and '.') and [:ambiguous:] (IUPAC ambiguity codes including N)
+		- regex: will replace all matches of the regular expression, the replacement can refer
+		  to capture groups with $1, ${name}, ...
+	With --id-regex, only sequences whose name matches the given regular expression are modified.`,
+	Args:        cobra.ExactArgs(2),
+	Annotations: map[string]string{argsAreNotFiles: ""},
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		records := make(chan seqs.SeqRecord)
-		errs := make(chan error)
-
-		go seqs.ReadFastaRecords(inputReader, records, errs)
-
-		for records != nil && errs != nil {
-			select {
-			case record := <-records:
-				output, err := record.Seq.FormatSeq(outputLineWidth)
-				if err != nil {
-					return err
-				}
-				_, err = fmt.Fprintf(outputWriter, ">%s\n%s\n", record.Name, strings.ReplaceAll(output, args[0], args[1]))
-				if err != nil {
-					return err
-				}
-			case err := <-errs:
+		var replace func(seq string) string
+
+		switch replaceMode {
+		case "string":
+			replace = func(seq string) string {
+				return strings.ReplaceAll(seq, args[0], args[1])
+			}
+		case "tr":
+			table, err := translationTable(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			replace = table.apply
+		case "regex":
+			pattern, err := regexp.Compile(args[0])
+			if err != nil {
 				return err
 			}
+			replace = func(seq string) string {
+				return pattern.ReplaceAllString(seq, args[1])
+			}
+		default:
+			return fmt.Errorf(
+				"mode %s not recognized.\n"+
+					"The mode must be one of the following values: "+
+					"'string' 'tr' 'regex'", replaceMode)
 		}
 
-		return nil
+		var idPattern *regexp.Regexp
+		if replaceIDRegex != "" {
+			var err error
+			if idPattern, err = regexp.Compile(replaceIDRegex); err != nil {
+				return err
+			}
+		}
+
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			if idPattern != nil && !idPattern.MatchString(recordKey(*record)) {
+				return nil
+			}
+			record.Seq = seqs.Seq(replace(string(record.Seq)))
+			if record.Qual != "" && len(record.Seq) != len(record.Qual) {
+				return fmt.Errorf("replacing %q with %q changes the length of %s, its qualities cannot be kept", args[0], args[1], record.Name)
+			}
+			return nil
+		})
 	},
 }
 
 // init adds the command to the root
 func init() {
 	transformCmd.AddCommand(replaceCmd)
+	replaceCmd.Flags().StringVarP(&replaceMode, "mode", "m", "string", "How to interpret the pattern and replacement")
+	replaceCmd.Flags().StringVar(&replaceIDRegex, "id-regex", "", "Only modify sequences whose name matches this regular expression")
+}
+
+// charClasses are the named character classes that can be used in tr sets
+var charClasses = map[string]string{
+	"lower":     "abcdefghijklmnopqrstuvwxyz",
+	"upper":     "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"alpha":     "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"digit":     "0123456789",
+	"gap":       "-.",
+	"ambiguous": "RYSWKMBDHVNryswkmbdhvn",
+}
+
+// expandSet returns the characters of a tr set, expanding ranges and character classes
+func expandSet(set string) (string, error) {
+	var chars []byte
+	for i := 0; i < len(set); i++ {
+		if strings.HasPrefix(set[i:], "[:") {
+			end := strings.Index(set[i:], ":]")
+			if end < 0 {
+				return "", fmt.Errorf("unterminated character class in %q", set)
+			}
+			class, ok := charClasses[set[i+2:i+end]]
+			if !ok {
+				return "", fmt.Errorf("character class %s not recognized, must be one of "+
+					"'lower' 'upper' 'alpha' 'digit' 'gap' 'ambiguous'", set[i+2:i+end])
+			}
+			chars = append(chars, class...)
+			i += end + 1
+			continue
+		}
+		if i+2 < len(set) && set[i+1] == '-' {
+			if set[i] > set[i+2] {
+				return "", fmt.Errorf("range %s is in reverse order", set[i:i+3])
+			}
+			for char := int(set[i]); char <= int(set[i+2]); char++ {
+				chars = append(chars, byte(char))
+			}
+			i += 2
+			continue
+		}
+		chars = append(chars, set[i])
+	}
+	return string(chars), nil
+}
+
+// trTable maps each character to its replacement, -1 leaving it unchanged and -2 deleting it
+type trTable [256]int
+
+// translationTable returns the table replacing characters of the first set by those of the second one.
+// The last character of the second set is repeated if it is shorter, and an empty second set deletes
+// the characters of the first one.
+func translationTable(from string, to string) (*trTable, error) {
+	fromChars, err := expandSet(from)
+	if err != nil {
+		return nil, err
+	}
+	toChars, err := expandSet(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var table trTable
+	for i := range table {
+		table[i] = -1
+	}
+	for i := 0; i < len(fromChars); i++ {
+		switch {
+		case len(toChars) == 0:
+			table[fromChars[i]] = -2
+		case i < len(toChars):
+			table[fromChars[i]] = int(toChars[i])
+		default:
+			table[fromChars[i]] = int(toChars[len(toChars)-1])
+		}
+	}
+	return &table, nil
+}
+
+// apply replaces the characters of the sequence according to the table
+func (table *trTable) apply(seq string) string {
+	replaced := make([]byte, 0, len(seq))
+	for i := 0; i < len(seq); i++ {
+		switch replacement := table[seq[i]]; replacement {
+		case -1:
+			replaced = append(replaced, seq[i])
+		case -2:
+		default:
+			replaced = append(replaced, byte(replacement))
+		}
+	}
+	return string(replaced)
 }
@@ -0,0 +1,258 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// column is a field of a result: Key is used in the tsv header and as json object key,
+// Title is the human readable name used in text summaries.
+type column struct {
+	Key   string
+	Title string
+}
+
+// resultWriter writes results as rows of values to the output stream in the format selected by --format:
+//   - text: tab separated values without header, unless `header` is set for tables whose columns depend
+//     on the input. Summaries are written with one "Title:" line per column and one value column per row,
+//     so that several rows are displayed side by side
+//   - tsv: tab separated values with a header line of column keys
+//   - json: an array of objects, with one key per column
+//   - jsonl: one json object per line
+//
+// Floating point values are written with `precision` decimals, or as few as needed if it is negative.
+// Output is buffered, Close must be called once all rows are written.
+type resultWriter struct {
+	output    *bufio.Writer
+	format    string
+	columns   []column
+	summary   bool
+	header    bool
+	precision int
+	rows      int
+	buffered  [][]string
+}
+
+// newResultWriter returns a resultWriter writing a table with the given columns
+func newResultWriter(columns ...column) *resultWriter {
+	output := bufio.NewWriterSize(outputWriter, 1<<16)
+	outputBuffers = append(outputBuffers, output)
+	return &resultWriter{
+		output:    output,
+		format:    outputFormat,
+		columns:   columns,
+		precision: -1,
+	}
+}
+
+// newSummaryWriter returns a resultWriter writing a summary with the given columns,
+// it differs from a table only in the text format
+func newSummaryWriter(columns ...column) *resultWriter {
+	writer := newResultWriter(columns...)
+	writer.summary = true
+	return writer
+}
+
+// statsColumns prepends the input file column to the columns of a statistics result when using --per-file
+func statsColumns(columns ...column) []column {
+	if !perFile {
+		return columns
+	}
+	return append([]column{{Key: "file", Title: "File"}}, columns...)
+}
+
+// statsRow prepends the group name to the values of a statistics result when using --per-file
+func statsRow(group string, values ...interface{}) []interface{} {
+	if !perFile {
+		return values
+	}
+	return append([]interface{}{group}, values...)
+}
+
+// Write writes a row of values, one for each column
+func (w *resultWriter) Write(values ...interface{}) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("got %d values for %d result columns", len(values), len(w.columns))
+	}
+	w.rows++
+
+	var err error
+	switch w.format {
+	case "json", "jsonl":
+		err = w.writeJSON(values)
+	case "tsv":
+		if w.rows == 1 {
+			if err = w.writeHeader(); err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintln(w.output, strings.Join(w.formatRow(values), "\t"))
+	default:
+		if w.summary {
+			w.buffered = append(w.buffered, w.formatRow(values))
+			return nil
+		}
+		if w.rows == 1 {
+			if err = w.writeHeader(); err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintln(w.output, strings.Join(w.formatRow(values), "\t"))
+	}
+
+	return err
+}
+
+// Close terminates the output, writing buffered summaries and closing json arrays, and flushes it
+func (w *resultWriter) Close() error {
+	if err := w.terminate(); err != nil {
+		return err
+	}
+	return w.output.Flush()
+}
+
+// terminate writes buffered summaries, closes json arrays and writes the header of empty tables
+func (w *resultWriter) terminate() error {
+	var err error
+	switch w.format {
+	case "json":
+		if w.rows == 0 {
+			_, err = fmt.Fprintln(w.output, "[]")
+		} else {
+			_, err = fmt.Fprint(w.output, "\n]\n")
+		}
+	case "tsv":
+		if w.rows == 0 {
+			err = w.writeHeader()
+		}
+	case "text":
+		if !w.summary {
+			if w.rows == 0 {
+				err = w.writeHeader()
+			}
+			break
+		}
+		for i, col := range w.columns {
+			line := make([]string, 0, len(w.buffered)+1)
+			line = append(line, col.Title+":")
+			for _, row := range w.buffered {
+				line = append(line, row[i])
+			}
+			if _, err = fmt.Fprintln(w.output, strings.Join(line, "\t")); err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// writeHeader writes the header line of a table: the column keys for the tsv format,
+// and the column titles for the text format if `header` is set
+func (w *resultWriter) writeHeader() error {
+	if w.format == "text" && !w.header {
+		return nil
+	}
+	names := make([]string, len(w.columns))
+	for i, col := range w.columns {
+		if w.format == "tsv" {
+			names[i] = col.Key
+		} else {
+			names[i] = col.Title
+		}
+	}
+	_, err := fmt.Fprintln(w.output, strings.Join(names, "\t"))
+	return err
+}
+
+// writeJSON writes a row as a json object, as an array element for the json format
+func (w *resultWriter) writeJSON(values []interface{}) error {
+	var builder strings.Builder
+
+	if w.format == "json" {
+		if w.rows == 1 {
+			builder.WriteString("[\n")
+		} else {
+			builder.WriteString(",\n")
+		}
+	}
+
+	builder.WriteString("{")
+	for i, value := range values {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		key, _ := json.Marshal(w.columns[i].Key)
+		builder.Write(key)
+		builder.WriteString(":")
+		builder.WriteString(w.formatJSON(value))
+	}
+	builder.WriteString("}")
+
+	if w.format == "jsonl" {
+		builder.WriteString("\n")
+	}
+
+	_, err := w.output.WriteString(builder.String())
+	return err
+}
+
+// formatRow formats a row of values as strings
+func (w *resultWriter) formatRow(values []interface{}) []string {
+	row := make([]string, len(values))
+	for i, value := range values {
+		row[i] = w.formatValue(value)
+	}
+	return row
+}
+
+// formatValue formats a single value as a string
+func (w *resultWriter) formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', w.precision, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', w.precision, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatJSON formats a single value as json, non finite numbers are written as null
+func (w *resultWriter) formatJSON(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "null"
+		}
+		return w.formatValue(v)
+	case float32:
+		return w.formatJSON(float64(v))
+	case int, int64, int32, uint64:
+		return w.formatValue(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "null"
+		}
+		return string(encoded)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var strandTag bool
+
+// revcompCmd represents the revcomp command
+var revcompCmd = &cobra.Command{
+	Use:   "revcomp",
+	Short: "Reverse complement sequences",
+	Long: `This command replaces each sequence by its reverse complement. All IUPAC ambiguity codes are
+	complemented, lowercase (soft-masked) bases stay lowercase and sequences containing U but no T are
+	complemented as RNA. The qualities of fastq records are reversed.
+	With --strand-tag, "strand=-" is added to the description of each sequence, or an existing
+	strand=+ or strand=- tag is flipped.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			record.Seq = record.Seq.ReverseComplement()
+			record.Qual = reverseQualities(record.Qual)
+			if strandTag {
+				record.SetDescription(flipStrandTag(record.Description))
+			}
+			return nil
+		})
+	},
+}
+
+// reverseCmd represents the reverse command
+var reverseCmd = &cobra.Command{
+	Use:   "reverse",
+	Short: "Reverse sequences without complementing them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			record.Seq = record.Seq.Reverse()
+			record.Qual = reverseQualities(record.Qual)
+			return nil
+		})
+	},
+}
+
+// complementCmd represents the complement command
+var complementCmd = &cobra.Command{
+	Use:   "complement",
+	Short: "Complement sequences without reversing them",
+	Long: `This command replaces each sequence by its complement, without reversing it. As with revcomp,
+	all IUPAC ambiguity codes are complemented, case is kept and RNA sequences are complemented with U.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			record.Seq = record.Seq.Complement()
+			return nil
+		})
+	},
+}
+
+// init adds the commands to transform and deals with flags
+func init() {
+	transformCmd.AddCommand(revcompCmd)
+	transformCmd.AddCommand(reverseCmd)
+	transformCmd.AddCommand(complementCmd)
+	revcompCmd.Flags().BoolVar(&strandTag, "strand-tag", false, "Add or flip a strand=+/- tag in sequence descriptions")
+}
+
+// reverseQualities returns fastq qualities read backwards
+func reverseQualities(qual string) string {
+	seq := seqs.Seq(qual)
+	return string(seq.Reverse())
+}
+
+// strandTagRegex matches an existing strand tag in a description
+var strandTagRegex = regexp.MustCompile(`(^|\s)strand=([+-])(\s|$)`)
+
+// flipStrandTag flips the strand tag of a description, or adds a "strand=-" tag if there is none
+func flipStrandTag(description string) string {
+	match := strandTagRegex.FindStringSubmatchIndex(description)
+	if match == nil {
+		return strings.TrimSpace(description + " strand=-")
+	}
+	flipped := "-"
+	if description[match[4]:match[5]] == "-" {
+		flipped = "+"
+	}
+	return description[:match[4]] + flipped + description[match[5]:]
+}
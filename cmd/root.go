@@ -16,7 +16,8 @@ limitations under the License.
 package cmd
 
 import (
-	"compress/bzip2"
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"errors"
 	"fmt"
@@ -24,18 +25,35 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
 	"github.com/ulikunitz/xz"
 )
 
-var inputFileName string
+var inputFileNames []string
 var outputFileName string
 var inputCompression string
+var outputCompression string
+var compressionLevel int
 var outputLineWidth int
+var outputCRLF bool
+var matchOn string
+var strict bool
+var outputFormat string
 
-var inputReader io.Reader
+var inputs []string
 var outputWriter io.Writer
+var outputClosers []io.Closer
+var outputBuffers []flusher
+
+// flusher is a buffered writer on the output stream
+type flusher interface {
+	Flush() error
+}
 
 // compAlg lists the supported I/O compression algorithms
 type compAlg string
@@ -44,28 +62,43 @@ const (
 	GZ   compAlg = "gz"
 	XZ   compAlg = "xz"
 	BZ2  compAlg = "bz2"
+	ZSTD compAlg = "zst"
 	None compAlg = ""
 )
 
 // isValid checks if a specified compression method is supported
 func (comp compAlg) isValid() error {
 	switch comp {
-	case None, GZ, XZ, BZ2:
+	case None, GZ, XZ, BZ2, ZSTD:
 		return nil
 	}
 	return errors.New("invalid compression method")
 }
 
+// argsAreNotFiles is the annotation set on commands whose positional arguments are not input files
+const argsAreNotFiles = "argsAreNotFiles"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "fastago",
 	Short: "Useful commands to work with fasta files",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if _, ok := cmd.Annotations[argsAreNotFiles]; ok {
+			args = nil
+		}
+		return initInputs(append(inputFileNames, args...))
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// The output stream is closed once the command has run, so that compressed output is properly terminated.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if closeErr := closeWriter(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -73,87 +106,331 @@ func Execute() {
 
 // init checks input flags and launches I/O initialization
 func init() {
-	cobra.OnInitialize(initReader, initWriter, checkCompression, checkLineWidth)
+	cobra.OnInitialize(checkCompression, checkLineWidth, checkMatchOn, checkFormat, initWriter)
 
-	rootCmd.PersistentFlags().StringVarP(&inputFileName, "input", "i", "",
-		"input file (default is stdin)")
+	rootCmd.PersistentFlags().StringArrayVarP(&inputFileNames, "input", "i", nil,
+		"input file or glob pattern, can be repeated. Files can also be given as arguments (default is stdin)")
 	rootCmd.PersistentFlags().StringVarP(&outputFileName, "output", "o", "",
 		"output file (default is stdout)")
 	rootCmd.PersistentFlags().StringVarP(&inputCompression, "compression", "c", "",
-		"compression mode of file (can be autodected from file extension) [xz, gz, bz2]")
+		"compression mode of input (detected from the first bytes of each file by default) [xz, gz, bz2, zst]")
+	rootCmd.PersistentFlags().StringVar(&outputCompression, "output-compression", "",
+		"compression mode of output (can be autodetected from output file extension) [xz, gz, bz2, zst]")
+	rootCmd.PersistentFlags().IntVar(&compressionLevel, "compression-level", -1,
+		"compression level of output, from 0 (fastest) to 9 (smallest), -1 for the default level. For xz only the dictionary size changes")
 	rootCmd.PersistentFlags().IntVarP(&outputLineWidth, "linewidth", "w", 80,
-		"linewidth for sequences in output")
+		"linewidth for sequences in output (0 to write each sequence on a single line)")
+	rootCmd.PersistentFlags().BoolVar(&outputCRLF, "crlf", false,
+		"use CRLF line endings in output")
+	rootCmd.PersistentFlags().StringVar(&matchOn, "match-on", "id",
+		"part of the sequence header used to identify sequences [id, full]")
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false,
+		"fail on malformed input instead of reading it leniently")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "text",
+		"output format of statistics [text, tsv, json, jsonl]")
 
 }
 
 // checkLineWidth Checks if the specified linewidth for the output is valid
 func checkLineWidth() {
-	if outputLineWidth <= 0 {
-		err := errors.New("output linewidth must be > 0")
+	if outputLineWidth < 0 {
+		err := errors.New("output linewidth must be >= 0")
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
-// checkCompression Checks if compression algorithm is valid
+// checkMatchOn Checks if the specified header matching mode is valid
+func checkMatchOn() {
+	if matchOn != "id" && matchOn != "full" {
+		err := fmt.Errorf("match-on mode %s not recognized, must be 'id' or 'full'", matchOn)
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// checkFormat Checks if the specified statistics output format is valid
+func checkFormat() {
+	switch outputFormat {
+	case "text", "tsv", "json", "jsonl":
+		return
+	}
+	err := fmt.Errorf("format %s not recognized, must be one of 'text' 'tsv' 'json' 'jsonl'", outputFormat)
+	fmt.Println(err)
+	os.Exit(1)
+}
+
+// checkCompression Checks if compression algorithms and level are valid
 func checkCompression() {
 	if err := (compAlg)(inputCompression).isValid(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	if err := (compAlg)(outputCompression).isValid(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if compressionLevel < -1 || compressionLevel > 9 {
+		err := errors.New("compression level must be between -1 and 9")
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
 
-// initWriter Initializes the output stream, either a specified file or stdout by default
+// initWriter Initializes the output stream, either a specified file or stdout by default.
+// If needed the stream is compressed, the compression being guessed from the file extension if not specified.
 func initWriter() {
+	var writer io.Writer
 	var err error
 
+	if outputCompression == "" && outputFileName != "" {
+		ext := compAlg(strings.TrimPrefix(filepath.Ext(outputFileName), "."))
+		if ext != None && ext.isValid() == nil {
+			outputCompression = string(ext)
+		}
+	}
+
 	if outputFileName != "" {
-		outputWriter, err = os.Create(outputFileName)
+		file, err := os.Create(outputFileName)
 		if err != nil {
 			log.Fatal(err)
 		}
+		outputClosers = append(outputClosers, file)
+		writer = file
 	} else {
-		outputWriter = os.Stdout
+		writer = os.Stdout
+	}
+
+	outputWriter, err = compress(outputCompression, writer, compressionLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if closer, ok := outputWriter.(io.Closer); ok && outputWriter != writer {
+		outputClosers = append(outputClosers, closer)
 	}
 }
 
-// initReader Initializes the input stream, either a specified file or stdin by default
-func initReader() {
-	var reader io.Reader
+// closeWriter flushes the output buffers, then closes the compression stream and output file in order,
+// returning the first error. Buffers are flushed here too so that the rows and records written before
+// a command failed are not lost.
+func closeWriter() error {
 	var err error
+	for _, buffer := range outputBuffers {
+		if flushErr := buffer.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	outputBuffers = nil
+	for i := len(outputClosers) - 1; i >= 0; i-- {
+		if closeErr := outputClosers[i].Close(); err == nil {
+			err = closeErr
+		}
+	}
+	outputClosers = nil
+	return err
+}
 
-	if inputFileName != "" {
-		reader, err = os.Open(inputFileName)
+// initInputs lists the input files, expanding glob patterns. Without any input file, stdin is read.
+func initInputs(names []string) error {
+	inputs = nil
+	for _, name := range names {
+		if name == "-" || !strings.ContainsAny(name, "*?[") {
+			inputs = append(inputs, name)
+			continue
+		}
+		matches, err := filepath.Glob(name)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-	} else {
-		reader = os.Stdin
+		if len(matches) == 0 {
+			return fmt.Errorf("no input file matches %s", name)
+		}
+		inputs = append(inputs, matches...)
+	}
+
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+
+	for _, name := range inputs {
+		if name == "-" {
+			continue
+		}
+		if _, err := os.Stat(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// inputFile is a decompressed input stream, closing it closes the decompressor and the underlying file
+type inputFile struct {
+	io.Reader
+	file io.Closer
+}
+
+// Close releases the decompressor, if it needs to be, and closes the underlying file
+func (input inputFile) Close() error {
+	if decompressor, ok := input.Reader.(io.Closer); ok {
+		decompressor.Close()
 	}
+	return input.file.Close()
+}
+
+// openInput opens an input file, or stdin for "-", with the compression given by the -c flag.
+// Unless specified, the compression is detected from the first bytes of the stream.
+func openInput(name string) (io.ReadCloser, error) {
+	return openCompressed(name, inputCompression)
+}
+
+// openCompressed opens a file, or stdin for "-", decompressing it with the given compression algorithm.
+// If `compression` is empty it is detected from the first bytes of the stream.
+func openCompressed(name string, compression string) (io.ReadCloser, error) {
+	var file io.ReadCloser
+	var err error
 
-	if inputCompression == "" && inputFileName != "" {
-		ext := filepath.Ext(inputFileName)[1:]
-		if ext != "fasta" && ext != "fa" {
-			inputCompression = ext
+	if name != "-" {
+		file, err = os.Open(name)
+		if err != nil {
+			return nil, err
 		}
+	} else {
+		file = io.NopCloser(os.Stdin)
 	}
 
-	inputReader, err = deCompress(inputCompression, &reader)
+	buffered := bufio.NewReader(file)
+	if compression == "" {
+		compression = string(sniffCompression(buffered))
+	}
+
+	var reader io.Reader = buffered
+	decompressed, err := deCompress(compression, &reader)
 	if err != nil {
-		log.Fatal(err)
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
 	}
+
+	return inputFile{Reader: decompressed, file: file}, nil
+}
+
+// magicNumbers are the first bytes of streams compressed with each algorithm.
+// BGZF files are gzip files made of several members and are read with the gzip decompressor.
+var magicNumbers = []struct {
+	alg   compAlg
+	magic []byte
+}{
+	{GZ, []byte{0x1f, 0x8b}},
+	{XZ, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{ZSTD, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// sniffCompression detects the compression algorithm of a stream from its first bytes, without consuming them
+func sniffCompression(reader *bufio.Reader) compAlg {
+	header, _ := reader.Peek(6)
+	for _, format := range magicNumbers {
+		if bytes.HasPrefix(header, format.magic) {
+			return format.alg
+		}
+	}
+	// bzip2 streams start with "BZh" followed by the block size from '1' to '9'
+	if len(header) >= 4 && bytes.HasPrefix(header, []byte("BZh")) && header[3] >= '1' && header[3] <= '9' {
+		return BZ2
+	}
+	return None
 }
 
 // deCompress decompresses the inputReader to plain text with a supported compression algorithm
 func deCompress(compAlg string, reader *io.Reader) (io.Reader, error) {
 	switch compAlg {
+	case "zst":
+		decoder, err := zstd.NewReader(*reader)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
 	case "gz":
 		return gzip.NewReader(*reader)
 	case "bz2":
-		return bzip2.NewReader(*reader), nil
+		return bzip2.NewReader(*reader, nil)
 	case "xz":
 		return xz.NewReader(*reader)
 	default:
 		return *reader, nil
 	}
 }
+
+// newReader returns a record reader over all the input files, validating records if the --strict flag is set
+func newReader() *seqs.Reader {
+	reader := seqs.NewMultiReader(inputs, openInput)
+	reader.Strict = strict
+	return reader
+}
+
+// newWriter returns a record writer on the output stream, laid out according to the output flags.
+// It is flushed once the command has run, even if it fails.
+func newWriter(format seqs.Format) *seqs.Writer {
+	writer := seqs.NewWriter(outputWriter, format)
+	writer.LineWidth = outputLineWidth
+	writer.CRLF = outputCRLF
+	outputBuffers = append(outputBuffers, writer)
+	return writer
+}
+
+// recordKey returns the part of the record header that identifies it, according to the --match-on flag
+func recordKey(record seqs.SeqRecord) string {
+	if matchOn == "full" {
+		return record.Name
+	}
+	return record.ID
+}
+
+// setRecordKey replaces the part of the record header that identifies it, according to the --match-on flag.
+// When matching on IDs the description is left unchanged.
+func setRecordKey(record *seqs.SeqRecord, key string) {
+	if matchOn == "full" {
+		record.SetName(key)
+		return
+	}
+	record.SetID(key)
+}
+
+// xzDictCaps are the dictionary sizes used by the xz utility for each compression preset
+var xzDictCaps = [...]int{1 << 18, 1 << 20, 1 << 21, 1 << 22, 1 << 22, 1 << 23, 1 << 23, 1 << 24, 1 << 25, 1 << 26}
+
+// bzip2BlockLevel converts a compression level from 0 to 9 to a bzip2 level from 1 to 9,
+// which sets the size of compressed blocks
+func bzip2BlockLevel(level int) int {
+	if level == 0 {
+		return 1
+	}
+	return level
+}
+
+// compress compresses the plain text written to the writer with a supported compression algorithm.
+// A `level` of -1 selects the default level of the algorithm.
+func compress(compAlg string, writer io.Writer, level int) (io.Writer, error) {
+	switch compAlg {
+	case "gz":
+		return gzip.NewWriterLevel(writer, level)
+	case "bz2":
+		config := bzip2.WriterConfig{}
+		if level >= 0 {
+			config.Level = bzip2BlockLevel(level)
+		}
+		return bzip2.NewWriter(writer, &config)
+	case "xz":
+		config := xz.WriterConfig{}
+		if level >= 0 {
+			config.DictCap = xzDictCaps[level]
+		}
+		return config.NewWriter(writer)
+	case "zst":
+		if level >= 0 {
+			return zstd.NewWriter(writer, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(writer)
+	default:
+		return writer, nil
+	}
+}
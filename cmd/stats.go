@@ -16,31 +16,189 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
-
+	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
 )
 
+var perFile bool
+
 // statsCmd represents the stats command
 var statsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Get statistics for sequences in the fasta file",
+	Long: `This command computes a summary of the sequences in a single pass over the input:
+	number of sequences, total, minimum, maximum, mean and median lengths, N50/L50, N90/L90, auN,
+	GC content, number of N and other ambiguous bases and number of gaps (runs of N).
+	With --per-file the summaries of all input files are displayed side by side.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		count, err := countSeqs(inputReader)
-		if err != nil {
-			return err
+		summaries := make(map[string]*summaryStats)
+
+		reader := newReader()
+		for reader.Scan() {
+			record := reader.Record()
+			group := statsGroup(record)
+			if summaries[group] == nil {
+				summaries[group] = &summaryStats{}
+			}
+			summaries[group].add(record.Seq)
 		}
-		_, err = fmt.Fprintf(outputWriter, "Sequence count:\t%d\n", count)
-		if err != nil {
+		if err := reader.Err(); err != nil {
 			return err
 		}
-		_, err = fmt.Fprint(outputWriter, "Other statistics...\n")
-		return err
+
+		results := newSummaryWriter(statsColumns(summaryColumns...)...)
+		results.precision = 2
+		for _, group := range statsGroups() {
+			summary := summaries[group]
+			if summary == nil {
+				summary = &summaryStats{}
+			}
+			if err := results.Write(statsRow(group, summary.values()...)...); err != nil {
+				return err
+			}
+		}
+
+		return results.Close()
 	},
 }
 
 // init adds the command to the root
 func init() {
 	rootCmd.AddCommand(statsCmd)
+	statsCmd.PersistentFlags().BoolVar(&perFile, "per-file", false, "Compute statistics separately for each input file")
+}
+
+// statsGroups returns the names of the groups statistics are computed for:
+// each input file with --per-file, or a single unnamed group for the whole input
+func statsGroups() []string {
+	if !perFile {
+		return []string{""}
+	}
+
+	groups := make([]string, 0, len(inputs))
+	seen := make(map[string]bool)
+	for _, name := range inputs {
+		if !seen[name] {
+			groups = append(groups, name)
+			seen[name] = true
+		}
+	}
+	return groups
+}
+
+// statsGroup returns the name of the group a record is counted in
+func statsGroup(record seqs.SeqRecord) string {
+	if !perFile {
+		return ""
+	}
+	return record.Source
+}
+
+// baseClass is the category of a character in a nucleotide sequence
+type baseClass uint8
+
+const (
+	otherBase baseClass = iota
+	atBase
+	gcBase
+	nBase
+	ambiguousBase
+)
+
+// baseClasses maps each character to its category
+var baseClasses = func() (classes [256]baseClass) {
+	for _, chars := range []struct {
+		chars string
+		class baseClass
+	}{
+		{"ATUatu", atBase},
+		{"GCgc", gcBase},
+		{"Nn", nBase},
+		{"RYSWKMBDHVryswkmbdhv", ambiguousBase},
+	} {
+		for _, char := range []byte(chars.chars) {
+			classes[char] = chars.class
+		}
+	}
+	return classes
+}()
+
+// summaryStats accumulates the statistics of the summary for a group of sequences
+type summaryStats struct {
+	lengths   []int
+	total     int
+	at        int
+	gc        int
+	n         int
+	ambiguous int
+	gaps      int
+}
+
+// summaryColumns are the columns of the summary
+var summaryColumns = []column{
+	{Key: "count", Title: "Sequence count"},
+	{Key: "total_length", Title: "Total length"},
+	{Key: "min_length", Title: "Minimum length"},
+	{Key: "max_length", Title: "Maximum length"},
+	{Key: "mean_length", Title: "Mean length"},
+	{Key: "median_length", Title: "Median length"},
+	{Key: "n50", Title: "N50"},
+	{Key: "l50", Title: "L50"},
+	{Key: "n90", Title: "N90"},
+	{Key: "l90", Title: "L90"},
+	{Key: "aun", Title: "auN"},
+	{Key: "gc_percent", Title: "GC (%)"},
+	{Key: "n_count", Title: "N count"},
+	{Key: "ambiguous_count", Title: "Ambiguous count"},
+	{Key: "gaps", Title: "Gaps"},
+}
+
+// add accumulates the statistics of a sequence
+func (stats *summaryStats) add(seq seqs.Seq) {
+	stats.lengths = append(stats.lengths, seq.Length())
+	stats.total += seq.Length()
+
+	inGap := false
+	for i := 0; i < len(seq); i++ {
+		switch baseClasses[seq[i]] {
+		case atBase:
+			stats.at++
+		case gcBase:
+			stats.gc++
+		case nBase:
+			stats.n++
+			if !inGap {
+				stats.gaps++
+			}
+			inGap = true
+			continue
+		case ambiguousBase:
+			stats.ambiguous++
+		}
+		inGap = false
+	}
+}
+
+// values returns the statistics in the order of summaryColumns
+func (stats *summaryStats) values() []interface{} {
+	sorted := sortedLengths(stats.lengths)
+	n50, l50 := contiguity(sorted, stats.total, 50)
+	n90, l90 := contiguity(sorted, stats.total, 90)
+
+	min, max, mean := 0, 0, 0.0
+	if len(sorted) > 0 {
+		min, max = sorted[len(sorted)-1], sorted[0]
+		mean = float64(stats.total) / float64(len(sorted))
+	}
+
+	gcPercent := 0.0
+	if stats.at+stats.gc > 0 {
+		gcPercent = 100 * float64(stats.gc) / float64(stats.at+stats.gc)
+	}
+
+	return []interface{}{
+		len(sorted), stats.total, min, max, mean, median(sorted),
+		n50, l50, n90, l90, areaUnderNx(sorted, stats.total),
+		gcPercent, stats.n, stats.ambiguous, stats.gaps,
+	}
 }
@@ -17,8 +17,6 @@ package cmd
 
 import (
 	"bufio"
-	"fmt"
-	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
 	"os"
 	"regexp"
@@ -30,8 +28,9 @@ var exclude bool
 
 // subsetCmd represents the subset command
 var subsetCmd = &cobra.Command{
-	Use:   "subset",
-	Short: "Subset sequences by name",
+	Use:         "subset",
+	Short:       "Subset sequences by name",
+	Annotations: map[string]string{argsAreNotFiles: ""},
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var err error
@@ -95,30 +94,23 @@ func readNames(filename string) (map[string]bool, error) {
 // subsetFromNames takes the map generated by readNames and prints the sequence whose name are in that map to the output stream
 func subsetFromNames(names map[string]bool) error {
 
-	records := make(chan seqs.SeqRecord)
-	errs := make(chan error)
+	reader := newReader()
+	writer := newWriter(reader.Format())
 
-	go seqs.ReadFastaRecords(inputReader, records, errs)
-
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			if names[record.Name] != exclude {
-				output, err := record.Seq.FormatSeq(outputLineWidth)
-				if err != nil {
-					return err
-				}
-				_, err = fmt.Fprintf(outputWriter, ">%s\n%s\n", record.Name, output)
-				if err != nil {
-					return err
-				}
+	for reader.Scan() {
+		record := reader.Record()
+		if names[recordKey(record)] != exclude {
+			if err := writer.Write(record); err != nil {
+				return err
 			}
-		case err := <-errs:
-			return err
 		}
 	}
 
-	return nil
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return writer.Flush()
 }
 
 // subsetFromRegex prints sequences whose name matches the regular expression to the output stream
@@ -128,29 +120,22 @@ func subsetFromRegex(expression string) error {
 		return err
 	}
 
-	records := make(chan seqs.SeqRecord)
-	errs := make(chan error)
+	reader := newReader()
+	writer := newWriter(reader.Format())
 
-	go seqs.ReadFastaRecords(inputReader, records, errs)
-
-	for records != nil && errs != nil {
-		select {
-		case record := <-records:
-			if regex.MatchString(record.Name) != exclude {
-				output, err := record.Seq.FormatSeq(outputLineWidth)
-				if err != nil {
-					return err
-				}
-				_, err = fmt.Fprintf(outputWriter, ">%s\n%s\n", record.Name, output)
-				if err != nil {
-					return err
-				}
+	for reader.Scan() {
+		record := reader.Record()
+		if regex.MatchString(recordKey(record)) != exclude {
+			if err := writer.Write(record); err != nil {
+				return err
 			}
-		case err := <-errs:
-			return err
 		}
 	}
 
-	return nil
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return writer.Flush()
 
 }
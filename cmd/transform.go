@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"github.com/lucblassel/fastago/pkg/seqs"
 	"github.com/spf13/cobra"
 )
 
@@ -29,3 +30,26 @@ var transformCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(transformCmd)
 }
+
+// transformRecords applies a transformation to each record of the input stream and writes
+// the transformed records to the output stream
+func transformRecords(transform func(record *seqs.SeqRecord) error) error {
+	reader := newReader()
+	writer := newWriter(reader.Format())
+
+	for reader.Scan() {
+		record := reader.Record()
+		if err := transform(&record); err != nil {
+			return err
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lucblassel/fastago/pkg/gencode"
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var translateTable int
+var translateFrames []string
+var stopAtStop bool
+var trimStop bool
+var frameSuffix bool
+
+// translateCmd represents the translate command
+var translateCmd = &cobra.Command{
+	Use:   "translate",
+	Short: "Translate nucleotide sequences to proteins",
+	Long: `This command translates nucleotide sequences to protein sequences with the NCBI genetic code
+	given by -t/--table (1, the standard code, by default). Codons containing IUPAC ambiguity codes are
+	translated when they can only code for one amino acid (e.g. GCN is A), or for B, Z or J, and to X otherwise.
+	The reading frames are selected with -f/--frame, as a comma separated list of 1, 2, 3, -1, -2, -3
+	(negative frames are read on the reverse complement) or "all" for the six frames.
+	When several frames are translated, or with --frame-suffix, "_frame=<frame>" is appended to sequence names.
+	With --stop proteins end before their first stop codon, and with --trim trailing stops are removed.
+	Output is always in fasta format.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		code, err := gencode.ByID(translateTable)
+		if err != nil {
+			return err
+		}
+		frames, err := parseFrames(translateFrames)
+		if err != nil {
+			return err
+		}
+		suffix := frameSuffix || len(frames) > 1
+
+		reader := newReader()
+		writer := newWriter(seqs.FASTA)
+
+		for reader.Scan() {
+			record := reader.Record()
+			var reverse seqs.Seq
+			for _, frame := range frames {
+				seq := record.Seq
+				if frame < 0 {
+					if reverse == "" {
+						reverse = record.Seq.ReverseComplement()
+					}
+					seq = reverse
+				}
+
+				translated := translateRecord(record, code, seq, frame, suffix)
+				if err := writer.Write(translated); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := reader.Err(); err != nil {
+			return err
+		}
+
+		return writer.Flush()
+	},
+}
+
+// init adds the command to transform and deals with flags
+func init() {
+	transformCmd.AddCommand(translateCmd)
+	translateCmd.Flags().IntVarP(&translateTable, "table", "t", 1, "NCBI genetic code number")
+	translateCmd.Flags().StringSliceVarP(&translateFrames, "frame", "f", []string{"1"}, "Comma separated reading frames to translate [1, 2, 3, -1, -2, -3, all]")
+	translateCmd.Flags().BoolVar(&stopAtStop, "stop", false, "End proteins before their first stop codon")
+	translateCmd.Flags().BoolVar(&trimStop, "trim", false, "Remove trailing stops from proteins")
+	translateCmd.Flags().BoolVar(&frameSuffix, "frame-suffix", false, "Append the frame to sequence names even when translating a single frame")
+}
+
+// parseFrames returns the reading frames given with the --frame flag
+func parseFrames(values []string) ([]int, error) {
+	var frames []int
+	for _, value := range values {
+		if value == "all" {
+			frames = append(frames, 1, 2, 3, -1, -2, -3)
+			continue
+		}
+		frame, err := strconv.Atoi(value)
+		if err != nil || frame == 0 || frame < -3 || frame > 3 {
+			return nil, fmt.Errorf("frame %s not recognized, must be one of 1, 2, 3, -1, -2, -3 or all", value)
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// translateRecord returns the protein record translated from `seq`, which is the sequence of the record
+// or its reverse complement for negative frames
+func translateRecord(record seqs.SeqRecord, code *gencode.Code, seq seqs.Seq, frame int, suffix bool) seqs.SeqRecord {
+	offset := frame - 1
+	if frame < 0 {
+		offset = -frame - 1
+	}
+	protein := ""
+	if offset < len(seq) {
+		protein = code.TranslateSeq(string(seq[offset:]))
+	}
+
+	if stopAtStop {
+		if i := strings.IndexByte(protein, gencode.Stop); i >= 0 {
+			protein = protein[:i]
+		}
+	}
+	if trimStop {
+		protein = strings.TrimRight(protein, string(gencode.Stop))
+	}
+
+	translated := record
+	translated.Seq = seqs.Seq(protein)
+	translated.Qual = ""
+	if suffix {
+		setRecordKey(&translated, fmt.Sprintf("%s_frame=%d", recordKey(record), frame))
+	}
+	return translated
+}
@@ -16,7 +16,6 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
 	"github.com/lucblassel/fastago/pkg/seqs"
 	"strings"
 
@@ -28,29 +27,10 @@ var upperCmd = &cobra.Command{
 	Use:   "upper",
 	Short: "Uppercase all sequence nucleotides",
 	RunE: func(cmd *cobra.Command, args []string) error {
-
-		records := make(chan seqs.SeqRecord)
-		errs := make(chan error)
-
-		go seqs.ReadFastaRecords(inputReader, records, errs)
-
-		for records != nil && errs != nil {
-			select {
-			case record := <-records:
-				output, err := record.Seq.FormatSeq(outputLineWidth)
-				if err != nil {
-					return err
-				}
-				_, err = fmt.Fprintf(outputWriter, ">%s\n%s\n", record.Name, strings.ToUpper(output))
-				if err != nil {
-					return err
-				}
-			case err := <-errs:
-				return err
-			}
-		}
-
-		return nil
+		return transformRecords(func(record *seqs.SeqRecord) error {
+			record.Seq = seqs.Seq(strings.ToUpper(string(record.Seq)))
+			return nil
+		})
 	},
 }
 
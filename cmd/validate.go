@@ -0,0 +1,87 @@
+/*
+Copyright © 2021 LUC BLASSEL
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lucblassel/fastago/pkg/seqs"
+	"github.com/spf13/cobra"
+)
+
+var validateAlphabet string
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check that the input is a well formed fasta or fastq file",
+	Long: `This command reads the whole input in strict mode and reports every problem found
+	with its line number: sequence lines before the first header, empty names or sequences,
+	duplicate IDs, characters outside of the alphabet and records cut short by the end of the input.
+	The alphabet can be chosen with the -a/--alphabet flag:
+		- any: all letters and '*', '-', '.' (default)
+		- dna: IUPAC nucleotide codes with T
+		- rna: IUPAC nucleotide codes with U
+		- protein: amino acid codes and '*'
+	The command fails if any problem is found.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		alphabet, err := seqs.AlphabetByName(validateAlphabet)
+		if err != nil {
+			return err
+		}
+
+		reader := newReader()
+		reader.Strict = true
+		reader.Alphabet = alphabet
+
+		valid, invalid := 0, 0
+		for {
+			_, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+
+			var parseErr *seqs.ParseError
+			if errors.As(err, &parseErr) {
+				invalid++
+				if _, err := fmt.Fprintln(outputWriter, parseErr); err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			valid++
+		}
+
+		if invalid > 0 {
+			return fmt.Errorf("found %d problem(s) in %s input", invalid, reader.Format())
+		}
+
+		_, err = fmt.Fprintf(outputWriter, "%d valid %s records\n", valid, reader.Format())
+		return err
+	},
+}
+
+// init adds the command to the root and deals with flags
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringVarP(&validateAlphabet, "alphabet", "a", "any", "Alphabet of the sequences [any, dna, rna, protein]")
+}
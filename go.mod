@@ -3,6 +3,8 @@ module github.com/lucblassel/fastago
 go 1.16
 
 require (
+	github.com/dsnet/compress v0.0.1
+	github.com/klauspost/compress v1.13.6
 	github.com/spf13/cobra v1.2.1
 	github.com/ulikunitz/xz v0.5.10
 )
@@ -0,0 +1,281 @@
+// Package gencode implements the NCBI genetic codes, used to translate
+// codons of nucleotide sequences to amino acids.
+package gencode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// bases is the order of nucleotides used by NCBI to lay out codon tables
+const bases = "TCAG"
+
+// Stop is the amino acid symbol of stop codons
+const Stop = '*'
+
+// Code is a genetic code, mapping each of the 64 codons to an amino acid or a stop.
+// Codons are indexed in the NCBI order: TTT, TTC, TTA, TTG, TCT, ... GGG.
+type Code struct {
+	ID   int
+	Name string
+
+	aminoAcids string
+	starts     string
+}
+
+// codes are the NCBI genetic codes, as published in https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi
+var codes = []*Code{
+	{1, "Standard",
+		"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------**--*----M---------------M----------------------------"},
+	{2, "Vertebrate Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG",
+		"----------**--------------------MMMM----------**---M------------"},
+	{3, "Yeast Mitochondrial",
+		"FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**----------------------MM---------------M------------"},
+	{4, "Mold, Protozoan, and Coelenterate Mitochondrial and Mycoplasma/Spiroplasma",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--MM------**-------M------------MMMM---------------M------------"},
+	{5, "Invertebrate Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG",
+		"---M------**--------------------MMMM---------------M------------"},
+	{6, "Ciliate, Dasycladacean and Hexamita Nuclear",
+		"FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	{9, "Echinoderm and Flatworm Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"----------**-----------------------M---------------M------------"},
+	{10, "Euplotid Nuclear",
+		"FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**-----------------------M----------------------------"},
+	{11, "Bacterial, Archaeal and Plant Plastid",
+		"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------**--*----M------------MMMM---------------M------------"},
+	{12, "Alternative Yeast Nuclear",
+		"FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**--*----M---------------M----------------------------"},
+	{13, "Ascidian Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG",
+		"---M------**----------------------MM---------------M------------"},
+	{14, "Alternative Flatworm Mitochondrial",
+		"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"-----------*-----------------------M----------------------------"},
+	{16, "Chlorophycean Mitochondrial",
+		"FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------*---*--------------------M----------------------------"},
+	{21, "Trematode Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"----------**-----------------------M---------------M------------"},
+	{22, "Scenedesmus obliquus Mitochondrial",
+		"FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"------*---*---*--------------------M----------------------------"},
+	{23, "Thraustochytrium Mitochondrial",
+		"FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--*-------**--*-----------------M--M---------------M------------"},
+	{24, "Rhabdopleuridae Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		"---M------**-------M---------------M---------------M------------"},
+	{25, "Candidate Division SR1 and Gracilibacteria",
+		"FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------**-----------------------M---------------M------------"},
+	{26, "Pachysolen tannophilus Nuclear",
+		"FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**--*----M---------------M----------------------------"},
+	{27, "Karyorelict Nuclear",
+		"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	{28, "Condylostoma Nuclear",
+		"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**--*--------------------M----------------------------"},
+	{29, "Mesodinium Nuclear",
+		"FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	{30, "Peritrich Nuclear",
+		"FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	{31, "Blastocrithidia Nuclear",
+		"FFLLSSSSYYEECCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**-----------------------M----------------------------"},
+	{33, "Cephalodiscidae Mitochondrial",
+		"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		"---M-------*-------M---------------M---------------M------------"},
+}
+
+// Standard is the standard genetic code, NCBI table 1
+var Standard = codes[0]
+
+// Codes returns all the NCBI genetic codes, by increasing ID
+func Codes() []*Code {
+	return codes
+}
+
+// ByID returns the NCBI genetic code with the given ID
+func ByID(id int) (*Code, error) {
+	ids := make([]string, len(codes))
+	for i, code := range codes {
+		if code.ID == id {
+			return code, nil
+		}
+		ids[i] = fmt.Sprint(code.ID)
+	}
+	return nil, fmt.Errorf("genetic code %d not recognized, must be one of %s", id, strings.Join(ids, ", "))
+}
+
+// String returns the ID and name of the genetic code
+func (code *Code) String() string {
+	return fmt.Sprintf("%d (%s)", code.ID, code.Name)
+}
+
+// Codons returns the 64 codons in the NCBI order
+func Codons() []string {
+	codons := make([]string, 0, 64)
+	for _, first := range bases {
+		for _, second := range bases {
+			for _, third := range bases {
+				codons = append(codons, string([]rune{first, second, third}))
+			}
+		}
+	}
+	return codons
+}
+
+// baseIndex returns the index of a nucleotide in the NCBI order, U being read as T, or -1 for other characters
+func baseIndex(base byte) int {
+	switch base {
+	case 'T', 't', 'U', 'u':
+		return 0
+	case 'C', 'c':
+		return 1
+	case 'A', 'a':
+		return 2
+	case 'G', 'g':
+		return 3
+	}
+	return -1
+}
+
+// CodonIndex returns the index of a codon in the NCBI order. Codons are case-insensitive and can be
+// written with U instead of T. It returns -1 if the codon is not made of 3 unambiguous nucleotides.
+func CodonIndex(codon string) int {
+	if len(codon) != 3 {
+		return -1
+	}
+	index := 0
+	for i := 0; i < 3; i++ {
+		base := baseIndex(codon[i])
+		if base < 0 {
+			return -1
+		}
+		index = index*4 + base
+	}
+	return index
+}
+
+// AminoAcid returns the amino acid coded by the codon at `index` in the NCBI order, '*' for stops
+func (code *Code) AminoAcid(index int) byte {
+	return code.aminoAcids[index]
+}
+
+// baseSets are the indices of the nucleotides each IUPAC code stands for, in the NCBI order
+var baseSets = map[byte][]int{
+	'T': {0}, 'U': {0}, 'C': {1}, 'A': {2}, 'G': {3},
+	'R': {2, 3}, 'Y': {0, 1}, 'S': {1, 3}, 'W': {0, 2}, 'K': {0, 3}, 'M': {1, 2},
+	'B': {0, 1, 3}, 'D': {0, 2, 3}, 'H': {0, 1, 2}, 'V': {1, 2, 3},
+	'N': {0, 1, 2, 3},
+}
+
+// ambiguousAminoAcids are the IUPAC codes of amino acids that cannot be told apart
+var ambiguousAminoAcids = map[string]byte{"DN": 'B', "EQ": 'Z', "IL": 'J'}
+
+// Translate returns the amino acid coded by a codon, '*' for stops. Codons are case-insensitive
+// and can be written with U instead of T. Codons containing IUPAC ambiguity codes are translated
+// if all the codons they stand for code for the same amino acid (e.g. GCN is A), or for one of
+// the ambiguous amino acids B (D or N), Z (E or Q) and J (I or L). Gap codons ("---") are
+// translated to '-', and other codons to 'X'.
+func (code *Code) Translate(codon string) byte {
+	if index := CodonIndex(codon); index >= 0 {
+		return code.aminoAcids[index]
+	}
+	if codon == "---" {
+		return '-'
+	}
+	if len(codon) != 3 {
+		return 'X'
+	}
+
+	var sets [3][]int
+	for i := 0; i < 3; i++ {
+		sets[i] = baseSets[strings.ToUpper(codon[i : i+1])[0]]
+		if sets[i] == nil {
+			return 'X'
+		}
+	}
+
+	var aminoAcids []byte
+	for _, first := range sets[0] {
+		for _, second := range sets[1] {
+			for _, third := range sets[2] {
+				aminoAcid := code.aminoAcids[first*16+second*4+third]
+				if !strings.ContainsRune(string(aminoAcids), rune(aminoAcid)) {
+					aminoAcids = append(aminoAcids, aminoAcid)
+				}
+			}
+		}
+	}
+
+	if len(aminoAcids) == 1 {
+		return aminoAcids[0]
+	}
+	sort.Slice(aminoAcids, func(i, j int) bool { return aminoAcids[i] < aminoAcids[j] })
+	if aminoAcid, ok := ambiguousAminoAcids[string(aminoAcids)]; ok {
+		return aminoAcid
+	}
+	return 'X'
+}
+
+// TranslateSeq translates a nucleotide sequence codon by codon from its first base,
+// a trailing incomplete codon is ignored
+func (code *Code) TranslateSeq(seq string) string {
+	protein := make([]byte, 0, len(seq)/3)
+	for i := 0; i+3 <= len(seq); i += 3 {
+		protein = append(protein, code.Translate(seq[i:i+3]))
+	}
+	return string(protein)
+}
+
+// IsStop returns true if the codon is a stop codon
+func (code *Code) IsStop(codon string) bool {
+	return code.Translate(codon) == Stop
+}
+
+// IsStart returns true if the codon can be used as an initiation codon
+func (code *Code) IsStart(codon string) bool {
+	index := CodonIndex(codon)
+	return index >= 0 && code.starts[index] == 'M'
+}
+
+// Synonyms returns the indices of codons coding for the same amino acid as the codon at `index`,
+// including itself. All the stop codons are synonyms.
+func (code *Code) Synonyms(index int) []int {
+	var synonyms []int
+	for i := 0; i < 64; i++ {
+		if code.aminoAcids[i] == code.aminoAcids[index] {
+			synonyms = append(synonyms, i)
+		}
+	}
+	return synonyms
+}
+
+// init checks that all genetic codes define the 64 codons and are sorted by ID
+func init() {
+	for _, code := range codes {
+		if len(code.aminoAcids) != 64 || len(code.starts) != 64 {
+			panic(fmt.Sprintf("genetic code %d does not define 64 codons", code.ID))
+		}
+	}
+	if !sort.SliceIsSorted(codes, func(i, j int) bool { return codes[i].ID < codes[j].ID }) {
+		panic("genetic codes are not sorted by ID")
+	}
+}
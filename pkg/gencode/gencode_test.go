@@ -0,0 +1,122 @@
+package gencode
+
+import (
+	"reflect"
+	"testing"
+)
+
+// mustCode returns the genetic code with the given ID, failing the test if it does not exist
+func mustCode(t *testing.T, id int) *Code {
+	t.Helper()
+	code, err := ByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		table int
+		codon string
+		want  byte
+	}{
+		{1, "ATG", 'M'},
+		{1, "TGA", '*'},
+		{1, "AGA", 'R'},
+		{1, "CTG", 'L'},
+		{1, "aug", 'M'},
+		{2, "TGA", 'W'},
+		{2, "AGA", '*'},
+		{2, "AGG", '*'},
+		{2, "ATA", 'M'},
+		{3, "CTG", 'T'},
+		{11, "TGA", '*'},
+		{12, "CTG", 'S'},
+		{1, "GCN", 'A'},
+		{1, "RAY", 'B'},
+		{1, "SAR", 'Z'},
+		{1, "MTT", 'J'},
+		{1, "ATH", 'I'},
+		{1, "NNN", 'X'},
+		{1, "---", '-'},
+		{1, "AC", 'X'},
+		{1, "A?G", 'X'},
+	}
+
+	for _, test := range tests {
+		if got := mustCode(t, test.table).Translate(test.codon); got != test.want {
+			t.Errorf("table %d: %s translated to %c, want %c", test.table, test.codon, got, test.want)
+		}
+	}
+}
+
+func TestTranslateSeq(t *testing.T) {
+	if got := Standard.TranslateSeq("ATGGCNTAAGG"); got != "MA*" {
+		t.Errorf("got %s, want MA*", got)
+	}
+}
+
+func TestCodonIndex(t *testing.T) {
+	codons := Codons()
+	if len(codons) != 64 || codons[0] != "TTT" || codons[63] != "GGG" {
+		t.Fatalf("codons are not in the NCBI order")
+	}
+	for i, codon := range codons {
+		if got := CodonIndex(codon); got != i {
+			t.Errorf("index of %s: got %d, want %d", codon, got, i)
+		}
+	}
+	for codon, want := range map[string]int{"uuu": 0, "ggg": 63, "NTT": -1, "TT": -1} {
+		if got := CodonIndex(codon); got != want {
+			t.Errorf("index of %s: got %d, want %d", codon, got, want)
+		}
+	}
+}
+
+func TestSynonyms(t *testing.T) {
+	bacterial := mustCode(t, 11)
+	tests := []struct {
+		codon string
+		want  []string
+	}{
+		{"ATG", []string{"ATG"}},
+		{"TGG", []string{"TGG"}},
+		{"CTG", []string{"TTA", "TTG", "CTT", "CTC", "CTA", "CTG"}},
+		{"TAA", []string{"TAA", "TAG", "TGA"}},
+	}
+
+	codons := Codons()
+	for _, test := range tests {
+		var got []string
+		for _, index := range bacterial.Synonyms(CodonIndex(test.codon)) {
+			got = append(got, codons[index])
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("synonyms of %s: got %v, want %v", test.codon, got, test.want)
+		}
+	}
+}
+
+func TestIsStart(t *testing.T) {
+	bacterial := mustCode(t, 11)
+	for _, codon := range []string{"ATG", "GTG", "TTG", "CTG", "ATT", "ATC", "ATA"} {
+		if !bacterial.IsStart(codon) {
+			t.Errorf("%s is a start codon of table 11", codon)
+		}
+	}
+	for _, codon := range []string{"AAA", "TGA", "NTG", "AT"} {
+		if bacterial.IsStart(codon) {
+			t.Errorf("%s is not a start codon of table 11", codon)
+		}
+	}
+}
+
+func TestByID(t *testing.T) {
+	if mustCode(t, 2).Name != "Vertebrate Mitochondrial" {
+		t.Errorf("table 2 has the wrong name")
+	}
+	if _, err := ByID(7); err == nil {
+		t.Errorf("table 7 does not exist, expected an error")
+	}
+}
@@ -0,0 +1,187 @@
+// Package protein computes physico-chemical properties of protein sequences,
+// with the same parameters as the ExPASy ProtParam tool.
+package protein
+
+import (
+	"math"
+	"strings"
+)
+
+// Standard is the set of the 20 standard amino acids
+const Standard = "ACDEFGHIKLMNPQRSTVWY"
+
+// Properties holds the physico-chemical properties of a protein sequence
+type Properties struct {
+	// Length is the number of residues, stops and gaps excluded
+	Length int
+	// MolecularWeight is the average molecular weight in Daltons
+	MolecularWeight float64
+	// IsoelectricPoint is the pH at which the protein has no net charge
+	IsoelectricPoint float64
+	// Gravy is the grand average of hydropathy on the Kyte-Doolittle scale
+	Gravy float64
+	// InstabilityIndex is the Guruprasad instability index, proteins above 40 are predicted unstable
+	InstabilityIndex float64
+	// Aromaticity is the fraction of F, W and Y residues
+	Aromaticity float64
+	// ExtinctionCoefficient is the molar extinction coefficient at 280 nm in water,
+	// assuming all pairs of cysteines form cystines
+	ExtinctionCoefficient int
+	// ExtinctionCoefficientReduced is the molar extinction coefficient at 280 nm in water,
+	// assuming all cysteines are reduced
+	ExtinctionCoefficientReduced int
+}
+
+// water is the average mass of a water molecule, lost at each peptide bond
+const water = 18.01528
+
+// masses are the average masses of free amino acids, ambiguous residues are given the
+// average mass of the amino acids they stand for
+var masses = map[byte]float64{
+	'A': 89.0932, 'C': 121.1582, 'D': 133.1027, 'E': 147.1293, 'F': 165.1891,
+	'G': 75.0666, 'H': 155.1546, 'I': 131.1729, 'K': 146.1876, 'L': 131.1729,
+	'M': 149.2113, 'N': 132.1179, 'P': 115.1305, 'Q': 146.1445, 'R': 174.2010,
+	'S': 105.0926, 'T': 119.1192, 'V': 117.1463, 'W': 204.2252, 'Y': 181.1885,
+	'U': 168.0532, 'O': 255.3134,
+	'B': (133.1027 + 132.1179) / 2,
+	'Z': (147.1293 + 146.1445) / 2,
+	'J': 131.1729,
+}
+
+// init gives the unknown residue X the average mass of the standard amino acids
+func init() {
+	var total float64
+	for _, residue := range []byte(Standard) {
+		total += masses[residue]
+	}
+	masses['X'] = total / float64(len(Standard))
+}
+
+// hydropathy is the Kyte-Doolittle hydropathy scale
+var hydropathy = map[byte]float64{
+	'A': 1.8, 'C': 2.5, 'D': -3.5, 'E': -3.5, 'F': 2.8, 'G': -0.4, 'H': -3.2,
+	'I': 4.5, 'K': -3.9, 'L': 3.8, 'M': 1.9, 'N': -3.5, 'P': -1.6, 'Q': -3.5,
+	'R': -4.5, 'S': -0.8, 'T': -0.7, 'V': 4.2, 'W': -0.9, 'Y': -1.3,
+}
+
+// Acid dissociation constants of charged groups, the pK of the terminal groups depend on the terminal residues
+var (
+	positivePKs    = map[byte]float64{'K': 10.0, 'R': 12.0, 'H': 5.98}
+	negativePKs    = map[byte]float64{'D': 4.05, 'E': 4.45, 'C': 9.0, 'Y': 10.0}
+	nTerminalPKs   = map[byte]float64{'A': 7.59, 'M': 7.0, 'S': 6.93, 'P': 8.36, 'T': 6.82, 'V': 7.44, 'E': 7.7}
+	cTerminalPKs   = map[byte]float64{'D': 4.55, 'E': 4.75}
+	defaultNTermPK = 9.0
+	defaultCTermPK = 2.0
+)
+
+// Analyze computes the properties of a protein sequence. The sequence is case-insensitive,
+// stops ('*') and gaps ('-', '.') are ignored.
+func Analyze(seq string) Properties {
+	residues := make([]byte, 0, len(seq))
+	for _, char := range []byte(strings.ToUpper(seq)) {
+		if char != '*' && char != '-' && char != '.' {
+			residues = append(residues, char)
+		}
+	}
+
+	properties := Properties{Length: len(residues)}
+	if len(residues) == 0 {
+		return properties
+	}
+
+	counts := make(map[byte]int)
+	for _, residue := range residues {
+		counts[residue]++
+		properties.MolecularWeight += masses[residue]
+		properties.Gravy += hydropathy[residue]
+	}
+	properties.MolecularWeight -= float64(len(residues)-1) * water
+	properties.Gravy /= float64(len(residues))
+
+	for i := 0; i+1 < len(residues); i++ {
+		properties.InstabilityIndex += instabilityWeight(residues[i], residues[i+1])
+	}
+	properties.InstabilityIndex *= 10 / float64(len(residues))
+
+	properties.Aromaticity = float64(counts['F']+counts['W']+counts['Y']) / float64(len(residues))
+
+	properties.ExtinctionCoefficientReduced = counts['W']*5500 + counts['Y']*1490
+	properties.ExtinctionCoefficient = properties.ExtinctionCoefficientReduced + counts['C']/2*125
+
+	properties.IsoelectricPoint = isoelectricPoint(counts, residues[0], residues[len(residues)-1])
+
+	return properties
+}
+
+// charge returns the net charge of a protein at a given pH
+func charge(pH float64, counts map[byte]int, nTermPK float64, cTermPK float64) float64 {
+	positive := 1 / (1 + math.Pow(10, pH-nTermPK))
+	for residue, pK := range positivePKs {
+		positive += float64(counts[residue]) / (1 + math.Pow(10, pH-pK))
+	}
+	negative := 1 / (1 + math.Pow(10, cTermPK-pH))
+	for residue, pK := range negativePKs {
+		negative += float64(counts[residue]) / (1 + math.Pow(10, pK-pH))
+	}
+	return positive - negative
+}
+
+// isoelectricPoint finds the pH at which the net charge of the protein is 0 by bisection
+func isoelectricPoint(counts map[byte]int, nTerminal byte, cTerminal byte) float64 {
+	nTermPK, ok := nTerminalPKs[nTerminal]
+	if !ok {
+		nTermPK = defaultNTermPK
+	}
+	cTermPK, ok := cTerminalPKs[cTerminal]
+	if !ok {
+		cTermPK = defaultCTermPK
+	}
+
+	low, high := 0.0, 14.0
+	for high-low > 0.0001 {
+		pH := (low + high) / 2
+		if charge(pH, counts, nTermPK, cTermPK) > 0 {
+			low = pH
+		} else {
+			high = pH
+		}
+	}
+	return (low + high) / 2
+}
+
+// instabilityWeight returns the instability weight of a dipeptide, dipeptides containing
+// a non-standard residue do not contribute to the instability index
+func instabilityWeight(x byte, y byte) float64 {
+	if !strings.ContainsRune(Standard, rune(x)) || !strings.ContainsRune(Standard, rune(y)) {
+		return 0
+	}
+	if weight, ok := instabilityWeights[x][y]; ok {
+		return weight
+	}
+	return 1
+}
+
+// instabilityWeights are the dipeptide instability weight values (DIWV) of Guruprasad et al. (1990),
+// instabilityWeights[x][y] being the weight of residue x followed by residue y. Weights that are not listed are 1.
+var instabilityWeights = map[byte]map[byte]float64{
+	'A': {'C': 44.94, 'D': -7.49, 'H': -7.49, 'P': 20.26},
+	'C': {'D': 20.26, 'H': 33.60, 'L': 20.26, 'M': 33.60, 'P': 20.26, 'Q': -6.54, 'T': 33.60, 'V': -6.54, 'W': 24.68},
+	'D': {'F': -6.54, 'K': -7.49, 'R': -6.54, 'S': 20.26, 'T': -14.03},
+	'E': {'C': 44.94, 'D': 20.26, 'E': 33.60, 'H': -6.54, 'I': 20.26, 'P': 20.26, 'Q': 20.26, 'S': 20.26, 'W': -14.03},
+	'F': {'D': 13.34, 'K': -14.03, 'P': 20.26, 'Y': 33.601},
+	'G': {'A': -7.49, 'E': -6.54, 'G': 13.34, 'I': -7.49, 'K': -7.49, 'N': -7.49, 'T': -7.49, 'W': 13.34, 'Y': -7.49},
+	'H': {'G': -9.37, 'F': -9.37, 'I': 44.94, 'K': 24.68, 'N': 24.68, 'P': -1.88, 'T': -6.54, 'W': -1.88, 'Y': 44.94},
+	'I': {'E': 44.94, 'H': 13.34, 'K': -7.49, 'L': 20.26, 'P': -1.88, 'V': -7.49},
+	'K': {'G': -7.49, 'I': -7.49, 'L': -7.49, 'M': 33.60, 'P': -6.54, 'Q': 24.64, 'R': 33.60, 'V': -7.49},
+	'L': {'K': -7.49, 'P': 20.26, 'Q': 33.60, 'R': 20.26, 'W': 24.68},
+	'M': {'A': 13.34, 'H': 58.28, 'M': -1.88, 'P': 44.94, 'Q': -6.54, 'R': -6.54, 'S': 44.94, 'T': -1.88, 'Y': 24.68},
+	'N': {'C': -1.88, 'F': -14.03, 'G': -14.03, 'I': 44.94, 'K': 24.68, 'P': -1.88, 'Q': -6.54, 'T': -7.49, 'W': -9.37},
+	'P': {'A': 20.26, 'C': -6.54, 'D': -6.54, 'E': 18.38, 'F': 20.26, 'M': -6.54, 'P': 20.26, 'Q': 20.26, 'R': -6.54, 'S': 20.26, 'V': 20.26, 'W': -1.88},
+	'Q': {'C': -6.54, 'D': 20.26, 'E': 20.26, 'F': -6.54, 'P': 20.26, 'Q': 20.26, 'S': 44.94, 'V': -6.54, 'Y': -6.54},
+	'R': {'G': -7.49, 'H': 20.26, 'N': 13.34, 'P': 20.26, 'Q': 20.26, 'R': 58.28, 'S': 44.94, 'W': 58.28, 'Y': -6.54},
+	'S': {'C': 33.60, 'E': 20.26, 'P': 44.94, 'Q': 20.26, 'R': 20.26, 'S': 20.26},
+	'T': {'E': 20.26, 'F': 13.34, 'G': -7.49, 'N': -14.03, 'Q': -6.54, 'W': -14.03},
+	'V': {'D': -14.03, 'G': -7.49, 'K': -1.88, 'P': 20.26, 'T': -7.49, 'Y': -6.54},
+	'W': {'A': -14.03, 'G': -9.37, 'H': 24.68, 'L': 13.34, 'M': 24.68, 'N': 13.34, 'T': -14.03, 'V': -7.49},
+	'Y': {'A': 24.68, 'D': 24.68, 'E': -6.54, 'G': -7.49, 'H': 13.34, 'M': 44.94, 'P': 13.34, 'R': -15.91, 'T': -7.49, 'W': -9.37, 'Y': 13.34},
+}
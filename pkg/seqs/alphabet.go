@@ -0,0 +1,50 @@
+package seqs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Alphabet is the set of characters allowed in a sequence. Alphabets are case-insensitive.
+type Alphabet struct {
+	name    string
+	allowed [256]bool
+}
+
+// NewAlphabet returns an Alphabet allowing all the characters in `chars`, in upper and lower case
+func NewAlphabet(name string, chars string) *Alphabet {
+	alphabet := &Alphabet{name: name}
+	for _, char := range []byte(strings.ToUpper(chars) + strings.ToLower(chars)) {
+		alphabet.allowed[char] = true
+	}
+	return alphabet
+}
+
+// Contains returns true if the character is allowed in the alphabet
+func (alphabet *Alphabet) Contains(char byte) bool {
+	return alphabet.allowed[char]
+}
+
+// String returns the name of the alphabet
+func (alphabet *Alphabet) String() string {
+	return alphabet.name
+}
+
+// Predefined alphabets, nucleotide alphabets include all the IUPAC ambiguity codes
+// and all of them allow '-' and '.' as gap characters.
+var (
+	DNA     = NewAlphabet("dna", "ACGTRYSWKMBDHVN-.")
+	RNA     = NewAlphabet("rna", "ACGURYSWKMBDHVN-.")
+	Protein = NewAlphabet("protein", "ACDEFGHIKLMNPQRSTVWYBZJXUO*-.")
+	Generic = NewAlphabet("any", "ABCDEFGHIJKLMNOPQRSTUVWXYZ*-.")
+)
+
+// AlphabetByName returns the predefined alphabet with the given name
+func AlphabetByName(name string) (*Alphabet, error) {
+	for _, alphabet := range []*Alphabet{DNA, RNA, Protein, Generic} {
+		if alphabet.name == name {
+			return alphabet, nil
+		}
+	}
+	return nil, fmt.Errorf("alphabet %s not recognized, must be one of 'dna' 'rna' 'protein' 'any'", name)
+}
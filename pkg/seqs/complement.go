@@ -0,0 +1,86 @@
+package seqs
+
+import "strings"
+
+// dnaComplements and rnaComplements are the complementary nucleotides of DNA and RNA IUPAC codes, in upper and lower case.
+// Characters that are not nucleotides are their own complement.
+var dnaComplements, rnaComplements = func() ([256]byte, [256]byte) {
+	var dna, rna [256]byte
+	for i := range dna {
+		dna[i], rna[i] = byte(i), byte(i)
+	}
+	for _, pair := range []string{"AT", "CG", "RY", "KM", "SS", "WW", "BV", "DH", "NN"} {
+		for _, pair := range []string{pair, string([]byte{pair[0] + 'a' - 'A', pair[1] + 'a' - 'A'})} {
+			dna[pair[0]], dna[pair[1]] = pair[1], pair[0]
+			rna[pair[0]], rna[pair[1]] = pair[1], pair[0]
+		}
+	}
+	rna['A'], rna['a'] = 'U', 'u'
+	rna['U'], rna['u'] = 'A', 'a'
+	dna['U'], dna['u'] = 'A', 'a'
+	return dna, rna
+}()
+
+// IsRNA returns true if the sequence contains uracil (U) and no thymine (T)
+func (seq *Seq) IsRNA() bool {
+	rna := false
+	for i := 0; i < len(*seq); i++ {
+		switch (*seq)[i] {
+		case 'T', 't':
+			return false
+		case 'U', 'u':
+			rna = true
+		}
+	}
+	return rna
+}
+
+// Reverse returns the sequence read backwards
+func (seq *Seq) Reverse() Seq {
+	reversed := make([]byte, len(*seq))
+	for i := 0; i < len(*seq); i++ {
+		reversed[len(*seq)-1-i] = (*seq)[i]
+	}
+	return Seq(reversed)
+}
+
+// complements returns the complement table of the sequence, RNA sequences are complemented with U
+func (seq *Seq) complements() *[256]byte {
+	if seq.IsRNA() {
+		return &rnaComplements
+	}
+	return &dnaComplements
+}
+
+// Complement returns the complementary sequence. All IUPAC ambiguity codes are complemented,
+// the case of each character is kept and other characters such as gaps are left unchanged.
+// RNA sequences, containing U and no T, are complemented with U instead of T.
+func (seq *Seq) Complement() Seq {
+	complements := seq.complements()
+	complement := make([]byte, len(*seq))
+	for i := 0; i < len(*seq); i++ {
+		complement[i] = complements[(*seq)[i]]
+	}
+	return Seq(complement)
+}
+
+// ReverseComplement returns the complementary sequence read backwards, as Complement does
+// it handles IUPAC ambiguity codes, case and RNA.
+func (seq *Seq) ReverseComplement() Seq {
+	complements := seq.complements()
+	reverse := make([]byte, len(*seq))
+	for i := 0; i < len(*seq); i++ {
+		reverse[len(*seq)-1-i] = complements[(*seq)[i]]
+	}
+	return Seq(reverse)
+}
+
+// Transcribe returns the RNA sequence transcribed from a DNA sequence, replacing T with U and keeping case
+func (seq *Seq) Transcribe() Seq {
+	return Seq(strings.NewReplacer("T", "U", "t", "u").Replace(string(*seq)))
+}
+
+// BackTranscribe returns the DNA sequence of an RNA sequence, replacing U with T and keeping case
+func (seq *Seq) BackTranscribe() Seq {
+	return Seq(strings.NewReplacer("U", "T", "u", "t").Replace(string(*seq)))
+}
@@ -0,0 +1,72 @@
+package seqs
+
+import "fmt"
+
+// ErrorKind is the type of problem reported by a ParseError
+type ErrorKind int
+
+const (
+	// OrphanSequence is sequence data found before the first header
+	OrphanSequence ErrorKind = iota
+	// EmptyName is a header without a sequence ID
+	EmptyName
+	// EmptySequence is a header that is not followed by any sequence
+	EmptySequence
+	// DuplicateID is a sequence ID that was already used by a previous record
+	DuplicateID
+	// IllegalCharacter is a character that is not part of the sequence alphabet
+	IllegalCharacter
+	// Truncated is a record that is cut short by the end of the input
+	Truncated
+	// InvalidHeader is a fastq header that does not start with '@'
+	InvalidHeader
+	// QualityLength is a fastq record with more quality values than bases
+	QualityLength
+)
+
+var errorKindNames = map[ErrorKind]string{
+	OrphanSequence:   "orphan sequence",
+	EmptyName:        "empty name",
+	EmptySequence:    "empty sequence",
+	DuplicateID:      "duplicate ID",
+	IllegalCharacter: "illegal character",
+	Truncated:        "truncated record",
+	InvalidHeader:    "invalid header",
+	QualityLength:    "quality length mismatch",
+}
+
+// String returns a short human readable description of the error kind
+func (kind ErrorKind) String() string {
+	if name, ok := errorKindNames[kind]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(kind))
+}
+
+// ParseError reports a malformed part of the input stream.
+// Line is the 1-based number of the line where the problem was found, in the input named
+// File when reading several inputs. A Reader returning a ParseError from Next can still
+// be used to read the following records.
+type ParseError struct {
+	File   string
+	Line   int
+	Kind   ErrorKind
+	Detail string
+}
+
+// Error formats the parse error with its location
+func (err *ParseError) Error() string {
+	location := fmt.Sprintf("line %d", err.Line)
+	if err.File != "" {
+		location = fmt.Sprintf("%s: %s", err.File, location)
+	}
+	if err.Detail == "" {
+		return fmt.Sprintf("%s: %s", location, err.Kind)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, err.Kind, err.Detail)
+}
+
+// parseError returns a ParseError located in the current input of the reader
+func (r *Reader) parseError(line int, kind ErrorKind, detail string) *ParseError {
+	return &ParseError{File: r.source, Line: line, Kind: kind, Detail: detail}
+}
@@ -0,0 +1,93 @@
+package seqs
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// strictErrors reads all the records of the input in strict mode and returns the parse errors
+// and the number of valid records
+func strictErrors(t *testing.T, input string) ([]*ParseError, int) {
+	t.Helper()
+	reader := NewReader(strings.NewReader(input))
+	reader.Strict = true
+
+	var parseErrors []*ParseError
+	valid := 0
+	for {
+		_, err := reader.Next()
+		if err == io.EOF {
+			return parseErrors, valid
+		}
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			parseErrors = append(parseErrors, parseErr)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		valid++
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		kind  ErrorKind
+		line  int
+		valid int
+	}{
+		{"orphan sequence", "ACGT\n>a\nAC\n", OrphanSequence, 1, 1},
+		{"empty name", ">a\nAC\n>\nAC\n", EmptyName, 3, 1},
+		{"empty sequence", ">a\n>b\nAC\n", EmptySequence, 1, 1},
+		{"empty sequence after blank lines", "\n\n>a\n>b\nAC\n", EmptySequence, 3, 1},
+		{"duplicate ID", ">a\nAC\n>a x\nGT\n", DuplicateID, 3, 1},
+		{"illegal character", ">a\nAC\nA1\n>b\nAC\n", IllegalCharacter, 3, 1},
+		{"fasta header at end of input", ">a\nAC\n>b\n", Truncated, 3, 1},
+		{"fastq without separator", "@a\nAC\nII\n@b\nAC\n", Truncated, 5, 0},
+		{"fastq without qualities", "@a\nAC\n+\nII\n@b\nAC\n+\n", Truncated, 7, 1},
+		{"fastq invalid header", "@a\nAC\n+\nII\nb\n", InvalidHeader, 5, 1},
+		{"fastq quality length", "@a\nAC\n+\nIII\n", QualityLength, 4, 0},
+		{"fastq illegal quality", "@a\nAC\n+\nI\x01\n", IllegalCharacter, 4, 0},
+	}
+
+	for _, test := range tests {
+		parseErrors, valid := strictErrors(t, test.input)
+		if len(parseErrors) != 1 {
+			t.Errorf("%s: got %d errors %v, want 1", test.name, len(parseErrors), parseErrors)
+			continue
+		}
+		if err := parseErrors[0]; err.Kind != test.kind || err.Line != test.line {
+			t.Errorf("%s: got %s at line %d, want %s at line %d", test.name, err.Kind, err.Line, test.kind, test.line)
+		}
+		if valid != test.valid {
+			t.Errorf("%s: got %d valid records, want %d", test.name, valid, test.valid)
+		}
+	}
+}
+
+func TestMissingFinalNewline(t *testing.T) {
+	tests := []struct {
+		input string
+		valid int
+	}{
+		{">a\nAC", 1},
+		{">a\nAC\n>b\nGT", 2},
+		{"@a\nAC\n+\nII", 1},
+		{"@a\nAC\n+\nII\n@b\nGT\n+\nII", 2},
+	}
+
+	for _, test := range tests {
+		parseErrors, valid := strictErrors(t, test.input)
+		if len(parseErrors) != 0 {
+			t.Errorf("%q: got errors %v, want none", test.input, parseErrors)
+		}
+		if valid != test.valid {
+			t.Errorf("%q: got %d valid records, want %d", test.input, valid, test.valid)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package seqs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Format is the file format of a sequence stream
+type Format int
+
+const (
+	FASTA Format = iota
+	FASTQ
+)
+
+// String returns the name of the format
+func (format Format) String() string {
+	if format == FASTQ {
+		return "fastq"
+	}
+	return "fasta"
+}
+
+// Format returns the format of the input stream. It is detected from the first
+// non blank character of the stream: '@' for fastq, anything else for fasta.
+// When reading several inputs, this is the format of the first one.
+func (r *Reader) Format() Format {
+	if r.detected {
+		return r.format
+	}
+
+	if r.input == nil && !r.nextSource() {
+		r.err = io.EOF
+	}
+	if r.input != nil {
+		r.format = r.detectFormat()
+	}
+	r.detected = true
+
+	return r.format
+}
+
+// detectFormat skips the blank characters at the start of the current input and
+// returns the format corresponding to the first non blank character.
+func (r *Reader) detectFormat() Format {
+	for {
+		next, err := r.input.Peek(1)
+		if err != nil {
+			return FASTA
+		}
+		switch next[0] {
+		case '\n':
+			r.lineNum++
+			fallthrough
+		case ' ', '\t', '\r':
+			if _, err := r.input.ReadByte(); err != nil {
+				return FASTA
+			}
+			continue
+		case '@':
+			return FASTQ
+		}
+		return FASTA
+	}
+}
+
+// nextFastq reads the next 4-part fastq record of the input stream.
+// Sequence and quality strings may be wrapped on several lines.
+func (r *Reader) nextFastq() (SeqRecord, error) {
+	var line []byte
+	var err error
+
+	for len(line) == 0 {
+		line, err = r.readLine()
+		if err != nil {
+			r.err = err
+			return SeqRecord{}, err
+		}
+	}
+
+	if line[0] != '@' {
+		return SeqRecord{}, r.parseError(r.lineNum, InvalidHeader, "fastq header should start with '@'")
+	}
+	name := string(bytes.TrimSpace(line[1:]))
+	r.nameLine = r.lineNum
+
+	r.seq = r.seq[:0]
+	for {
+		line, err = r.readLine()
+		if err != nil {
+			return SeqRecord{}, r.truncated(name, err)
+		}
+		if len(line) > 0 && line[0] == '+' {
+			break
+		}
+		if r.Strict {
+			r.checkChars(line, r.alphabet())
+		}
+		r.seq = append(r.seq, line...)
+	}
+
+	r.qual = r.qual[:0]
+	for len(r.qual) < len(r.seq) {
+		line, err = r.readLine()
+		if err != nil {
+			return SeqRecord{}, r.truncated(name, err)
+		}
+		if r.Strict {
+			r.checkChars(line, qualities)
+		}
+		r.qual = append(r.qual, line...)
+	}
+
+	if len(r.qual) != len(r.seq) {
+		r.invalid = nil
+		return SeqRecord{}, r.parseError(r.lineNum, QualityLength, fmt.Sprintf("%d quality values for %d bases in %s", len(r.qual), len(r.seq), name))
+	}
+
+	record := SeqRecord{Seq: Seq(r.seq), Qual: string(r.qual)}
+	record.SetName(name)
+
+	if err := r.check(record); err != nil {
+		return SeqRecord{}, err
+	}
+
+	return record, nil
+}
+
+// qualities is the set of characters allowed in fastq quality strings
+var qualities = &Alphabet{name: "quality", allowed: func() (allowed [256]bool) {
+	for char := '!'; char <= '~'; char++ {
+		allowed[char] = true
+	}
+	return allowed
+}()}
+
+// truncated records the error met while reading an incomplete fastq record,
+// the end of the input is reported as a Truncated *ParseError.
+func (r *Reader) truncated(name string, err error) error {
+	r.err = err
+	r.invalid = nil
+	if err == io.EOF {
+		return r.parseError(r.lineNum, Truncated, name)
+	}
+	return err
+}
+
+// WriteFastq writes a record to the `output` stream in the 4-line fastq format
+func WriteFastq(output io.Writer, record SeqRecord) error {
+	_, err := fmt.Fprintf(output, "@%s\n%s\n+\n%s\n", record.Name, record.Seq, record.Qual)
+	return err
+}
@@ -0,0 +1,69 @@
+package seqs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Opener opens the input stream with the given name
+type Opener func(name string) (io.ReadCloser, error)
+
+// NewMultiReader returns a Reader reading the records of several inputs one after the other,
+// as a single logical stream. Each input is opened with `open` only when the previous one
+// is exhausted, and closed once it has been read. Records are tagged with the name of
+// the input they come from in their Source field.
+// All inputs must be in the same format.
+func NewMultiReader(names []string, open Opener) *Reader {
+	return &Reader{sources: names, open: open}
+}
+
+// Source returns the name of the input currently being read
+func (r *Reader) Source() string {
+	return r.source
+}
+
+// nextSource closes the current input and opens the next one. It returns false when there is
+// no input left, an error while switching inputs is recorded as the reader error.
+func (r *Reader) nextSource() bool {
+	if r.closer != nil {
+		err := r.closer.Close()
+		r.closer = nil
+		if err != nil {
+			r.err = err
+			return false
+		}
+	}
+
+	if len(r.sources) == 0 {
+		return false
+	}
+	name := r.sources[0]
+	r.sources = r.sources[1:]
+
+	input, err := r.open(name)
+	if err != nil {
+		r.err = err
+		return true
+	}
+
+	if r.input == nil {
+		r.input = bufio.NewReaderSize(input, 1<<16)
+	} else {
+		r.input.Reset(input)
+	}
+	r.closer = input
+	r.source = name
+	r.lineNum = 0
+	r.inRecord = false
+	r.orphan = false
+	r.err = nil
+
+	if r.detected {
+		if format := r.detectFormat(); format != r.format {
+			r.err = fmt.Errorf("input %s is in %s format but previous inputs are in %s format", name, format, r.format)
+		}
+	}
+
+	return true
+}
@@ -1,11 +1,13 @@
-// Package seqs allows to read a fasta formatted input stream as a
+// Package seqs allows to read a fasta or fastq formatted input stream as a
 // succession of whole sequences. Each sequence being a struct containing
-// its name and the full biological sequence.
+// its name, the full biological sequence and, for fastq, its qualities.
 package seqs
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 )
@@ -13,10 +15,64 @@ import (
 // The Seq type is a string representation of a biological sequence
 type Seq string
 
-// SeqRecord holds the string representation of a sequence and it's name
+// SeqRecord holds the string representation of a sequence and it's name.
+// Name is the full header line, it is split into the sequence ID (up to the first whitespace)
+// and the Description (the rest of the line). SetName, SetID and SetDescription keep the three in sync,
+// reusing the whitespace that separated the ID from the description in the original header.
+// Qual holds the per-base quality string of fastq records and is empty for fasta records.
+// Source is the name of the input the record was read from when reading several inputs.
 type SeqRecord struct {
-	Name string
-	Seq  Seq
+	Name        string
+	ID          string
+	Description string
+	Seq         Seq
+	Qual        string
+	Source      string
+
+	separator string
+}
+
+// ParseHeader splits a header line into the sequence ID and its description
+func ParseHeader(header string) (id string, description string) {
+	id, _, description = splitHeader(header)
+	return id, description
+}
+
+// splitHeader splits a header line into the sequence ID, the whitespace separating it
+// from the description and the description itself
+func splitHeader(header string) (id string, separator string, description string) {
+	header = strings.TrimSpace(header)
+	i := strings.IndexAny(header, " \t")
+	if i < 0 {
+		return header, "", ""
+	}
+	description = strings.TrimLeft(header[i:], " \t")
+	return header[:i], header[i : len(header)-len(description)], description
+}
+
+// SetName sets the full header of the record, updating its ID and description
+func (record *SeqRecord) SetName(name string) {
+	record.Name = name
+	record.ID, record.separator, record.Description = splitHeader(name)
+}
+
+// SetID changes the ID of the record, leaving its description unchanged
+func (record *SeqRecord) SetID(id string) {
+	record.ID = id
+	record.Name = id
+	if record.Description != "" {
+		separator := record.separator
+		if separator == "" {
+			separator = " "
+		}
+		record.Name += separator + record.Description
+	}
+}
+
+// SetDescription changes the description of the record, leaving its ID unchanged
+func (record *SeqRecord) SetDescription(description string) {
+	record.Description = description
+	record.SetID(record.ID)
 }
 
 // Length returns the number of characters in a sequence
@@ -48,39 +104,309 @@ func (seq *Seq) FormatSeq(width int) (string, error) {
 	return strings.Join(chunks, "\n"), nil
 }
 
-// ReadFastaRecords takes a fasta formated input stream and outputs a collection of SeqRecords to the `output` channels.
-func ReadFastaRecords(input io.Reader, output chan SeqRecord, errs chan error) {
-	defer close(output)
-	defer close(errs)
+// Reader reads SeqRecords one at a time from a fasta or fastq formatted input stream.
+// The format is detected from the first character of the stream.
+// Records can either be pulled with Next, or iterated over with Scan, Record and Err
+// in the same fashion as a bufio.Scanner.
+//
+// Lines are read regardless of their length and sequences are assembled in a growable
+// byte buffer, so that records spanning a single very long line or millions of short
+// lines are both read in linear time.
+//
+// By default the Reader is lenient and accepts anything resembling a fasta file.
+// When Strict is set, records are validated and problems are reported as *ParseError:
+// orphan sequence lines, empty names or sequences, duplicate IDs, characters outside
+// of Alphabet (Generic if nil) and records cut short by the end of the input.
+// A missing newline at the end of the input is not an error.
+type Reader struct {
+	Strict   bool
+	Alphabet *Alphabet
+
+	input    *bufio.Reader
+	closer   io.Closer
+	source   string
+	sources  []string
+	open     Opener
+	line     []byte
+	lineNum  int
+	name     string
+	nameLine int
+	seq      []byte
+	qual     []byte
+	inRecord bool
+	orphan   bool
+	invalid  *ParseError
+	ids      map[string]bool
+	format   Format
+	detected bool
+	record   SeqRecord
+	err      error
+}
+
+// NewReader returns a Reader reading records from the `input` stream
+func NewReader(input io.Reader) *Reader {
+	return &Reader{input: bufio.NewReaderSize(input, 1<<16)}
+}
+
+// readLine returns the next line of the input stream stripped of surrounding whitespace.
+// The returned slice is only valid until the next call to readLine.
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.input.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		r.line, err = r.appendLine(r.line[:0], line)
+		line = r.line
+	}
+	return r.endLine(line, err)
+}
+
+// readFastaLine returns the next line of a fasta input like readLine, except that sequence lines longer
+// than the buffer of the reader are appended to r.seq as they are read instead of being assembled
+// in r.line first, so that single line chromosomes are copied once. The returned line is then the end
+// of r.seq and `appended` is true.
+func (r *Reader) readFastaLine() (line []byte, appended bool, err error) {
+	line, err = r.input.ReadSlice('\n')
+	if err != bufio.ErrBufferFull || bytes.HasPrefix(bytes.TrimSpace(line), []byte(">")) {
+		if err == bufio.ErrBufferFull {
+			r.line, err = r.appendLine(r.line[:0], line)
+			line = r.line
+		}
+		line, err = r.endLine(line, err)
+		return line, false, err
+	}
+
+	start := len(r.seq)
+	r.seq, err = r.appendLine(r.seq, line)
+	line, err = r.endLine(r.seq[start:], err)
+	if len(line) > 0 && &line[0] != &r.seq[start] {
+		copy(r.seq[start:], line)
+	}
+	r.seq = r.seq[:start+len(line)]
+	return r.seq[start:], true, err
+}
+
+// appendLine appends a line longer than the buffer of the reader to `dst`, `chunk` being its first part
+func (r *Reader) appendLine(dst []byte, chunk []byte) ([]byte, error) {
+	dst = append(dst, chunk...)
+	err := bufio.ErrBufferFull
+	for err == bufio.ErrBufferFull {
+		chunk, err = r.input.ReadSlice('\n')
+		dst = append(dst, chunk...)
+	}
+	return dst, err
+}
+
+// endLine counts a line read from the input stream and strips it of surrounding whitespace.
+// A last line without a line terminator is not an error.
+func (r *Reader) endLine(line []byte, err error) ([]byte, error) {
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err == nil {
+		r.lineNum++
+	}
+	return bytes.TrimSpace(line), err
+}
+
+// checkChars records an IllegalCharacter error for the current record if the line contains
+// characters that are not part of the reader's alphabet.
+func (r *Reader) checkChars(line []byte, alphabet *Alphabet) {
+	if r.invalid != nil {
+		return
+	}
+	for i, char := range line {
+		if !alphabet.Contains(char) {
+			r.invalid = r.parseError(r.lineNum, IllegalCharacter, fmt.Sprintf("%q at column %d", char, i+1))
+			return
+		}
+	}
+}
+
+// check validates the record read from the header at line `nameLine` in strict mode.
+// Only the first problem found in a record is reported, but its ID is always
+// remembered so that later duplicates are caught.
+func (r *Reader) check(record SeqRecord) error {
+	if !r.Strict {
+		return nil
+	}
+
+	err := r.invalid
+	r.invalid = nil
+
+	if r.ids == nil {
+		r.ids = make(map[string]bool)
+	}
+
+	switch {
+	case record.ID == "":
+		if err == nil {
+			err = r.parseError(r.nameLine, EmptyName, "")
+		}
+	case r.ids[record.ID]:
+		if err == nil {
+			err = r.parseError(r.nameLine, DuplicateID, record.ID)
+		}
+	default:
+		r.ids[record.ID] = true
+	}
 
-	scanner := bufio.NewScanner(input)
+	if err == nil && record.Seq.Length() == 0 {
+		err = r.parseError(r.nameLine, EmptySequence, record.ID)
+	}
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// alphabet returns the alphabet used to validate sequences in strict mode
+func (r *Reader) alphabet() *Alphabet {
+	if r.Alphabet == nil {
+		return Generic
+	}
+	return r.Alphabet
+}
+
+// flush returns the record currently being assembled and resets the sequence buffer,
+// keeping its capacity for the next record.
+func (r *Reader) flush() (SeqRecord, error) {
+	record := SeqRecord{Seq: Seq(r.seq)}
+	record.SetName(r.name)
+	r.seq = r.seq[:0]
+
+	if err := r.check(record); err != nil {
+		return SeqRecord{}, err
+	}
+	return record, nil
+}
+
+// Next returns the next SeqRecord of the input stream. Once all records are read
+// it returns io.EOF, a *ParseError means that the current record is malformed and was
+// skipped, any other error means that the input could not be read.
+func (r *Reader) Next() (SeqRecord, error) {
+	format := r.Format()
+
+	for {
+		if r.err == io.EOF && r.nextSource() {
+			continue
+		}
+		if r.err != nil {
+			return SeqRecord{}, r.err
+		}
 
-	var seq Seq
-	name := ""
+		var record SeqRecord
+		var err error
+		if format == FASTQ {
+			record, err = r.nextFastq()
+		} else {
+			record, err = r.nextFasta()
+		}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+		if err == io.EOF {
+			continue
+		}
+		record.Source = r.source
+		return record, err
+	}
+}
+
+// nextFasta reads the next fasta record of the input stream
+func (r *Reader) nextFasta() (SeqRecord, error) {
+	for {
+		line, appended, err := r.readFastaLine()
+		if err != nil {
+			r.err = err
+			break
+		}
 
-		if line == "" {
+		if len(line) == 0 {
 			continue
 		}
 
 		if line[0] == '>' {
-			if name != "" {
-				output <- SeqRecord{Name: name, Seq: seq}
+			name := string(bytes.TrimSpace(line[1:]))
+			r.orphan = false
+			if r.inRecord {
+				record, err := r.flush()
+				r.name, r.nameLine = name, r.lineNum
+				return record, err
 			}
-			name = strings.TrimSpace(line[1:])
-			seq = ""
-		} else {
-			seq += Seq(strings.TrimSpace(line))
+			r.name, r.nameLine, r.inRecord = name, r.lineNum, true
+			continue
 		}
 
+		if !r.inRecord {
+			// sequence lines before the first header are not part of any record
+			if appended {
+				r.seq = r.seq[:len(r.seq)-len(line)]
+			}
+			if r.Strict && !r.orphan {
+				r.orphan = true
+				return SeqRecord{}, r.parseError(r.lineNum, OrphanSequence, "")
+			}
+			continue
+		}
+		if r.Strict {
+			r.checkChars(line, r.alphabet())
+		}
+		if !appended {
+			r.seq = append(r.seq, line...)
+		}
+	}
+
+	if r.err != io.EOF {
+		return SeqRecord{}, r.err
+	}
+
+	if r.inRecord {
+		r.inRecord = false
+		if r.Strict && len(r.seq) == 0 {
+			r.invalid = nil
+			return SeqRecord{}, r.parseError(r.nameLine, Truncated, "header without sequence at end of input")
+		}
+		return r.flush()
+	}
+
+	return SeqRecord{}, io.EOF
+}
+
+// Scan advances the Reader to the next record, which is then available through Record.
+// It returns false when there are no more records or when an error occurred, including
+// a *ParseError in strict mode.
+func (r *Reader) Scan() bool {
+	record, err := r.Next()
+	if err != nil {
+		r.err = err
+		return false
+	}
+	r.record = record
+	return true
+}
+
+// Record returns the last record read by a call to Scan
+func (r *Reader) Record() SeqRecord {
+	return r.record
+}
+
+// Err returns the first non-EOF error encountered by the Reader
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return nil
 	}
+	return r.err
+}
+
+// ReadFastaRecords takes a fasta formated input stream and outputs a collection of SeqRecords to the `output` channels.
+// It is a channel based adapter around Reader kept for backwards compatibility, the error channel receives
+// nil once all the records have been sent.
+func ReadFastaRecords(input io.Reader, output chan SeqRecord, errs chan error) {
+	defer close(output)
+	defer close(errs)
 
-	if err := scanner.Err(); err != nil {
-		errs <- err
+	reader := NewReader(input)
+	for reader.Scan() {
+		output <- reader.Record()
 	}
 
-	output <- SeqRecord{Name: name, Seq: seq}
-	errs <- nil
+	errs <- reader.Err()
 }
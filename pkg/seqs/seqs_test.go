@@ -0,0 +1,176 @@
+package seqs
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// readAll reads all the records of a fasta or fastq formatted string
+func readAll(t *testing.T, input string) []SeqRecord {
+	t.Helper()
+	reader := NewReader(strings.NewReader(input))
+	var records []SeqRecord
+	for reader.Scan() {
+		records = append(records, reader.Record())
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return records
+}
+
+func TestReadLongLine(t *testing.T) {
+	// lines longer than the 64 KiB buffer of the reader are read in several chunks
+	seq := strings.Repeat("ACGT", 100000)
+	records := readAll(t, ">long\n"+seq+"\n>short\nAC\n")
+
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0].Name != "long" || string(records[0].Seq) != seq {
+		t.Errorf("long record: got %s of length %d, want long of length %d", records[0].Name, len(records[0].Seq), len(seq))
+	}
+	if records[1].Name != "short" || records[1].Seq != "AC" {
+		t.Errorf("short record: got %s %s, want short AC", records[1].Name, records[1].Seq)
+	}
+}
+
+func TestReadLongHeader(t *testing.T) {
+	name := strings.Repeat("x", 100000)
+	records := readAll(t, ">"+name+"\nACGT\n")
+
+	if len(records) != 1 || records[0].Name != name || records[0].Seq != "ACGT" {
+		t.Errorf("record with a long header was not read correctly")
+	}
+}
+
+func TestReadWrapped(t *testing.T) {
+	records := readAll(t, ">a desc\nAC\nGT\n\n>b\r\nTT\r\nAA\r\n")
+
+	want := []struct{ name, id, description, seq string }{
+		{"a desc", "a", "desc", "ACGT"},
+		{"b", "b", "", "TTAA"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i, w := range want {
+		r := records[i]
+		if r.Name != w.name || r.ID != w.id || r.Description != w.description || string(r.Seq) != w.seq {
+			t.Errorf("record %d: got %+v, want %+v", i, r, w)
+		}
+	}
+}
+
+func TestLenientOrphanLines(t *testing.T) {
+	// sequence lines before the first header are skipped in lenient mode
+	for _, orphan := range []string{"AC", strings.Repeat("AC", 50000)} {
+		records := readAll(t, orphan+"\n>a\nGT\n")
+
+		if len(records) != 1 || records[0].Name != "a" || records[0].Seq != "GT" {
+			t.Errorf("orphan line of length %d: got %d records, want a single record a GT", len(orphan), len(records))
+		}
+	}
+}
+
+func TestReadLongLineWhitespace(t *testing.T) {
+	// long sequence lines are stripped of surrounding whitespace like short ones
+	seq := strings.Repeat("ACGT", 50000)
+	records := readAll(t, ">a\n  "+seq+" \r\nAC\n")
+
+	if len(records) != 1 || string(records[0].Seq) != seq+"AC" {
+		t.Errorf("long line with surrounding whitespace was not read correctly")
+	}
+}
+
+// benchmarkInput builds a fasta file of `count` records of `length` bases, wrapped at `width` (0 for a single line)
+func benchmarkInput(count int, length int, width int) []byte {
+	seq := bytes.Repeat([]byte("ACGT"), length/4+1)[:length]
+	var input bytes.Buffer
+	for i := 0; i < count; i++ {
+		input.WriteString(">seq\n")
+		if width == 0 {
+			input.Write(seq)
+			input.WriteByte('\n')
+			continue
+		}
+		for start := 0; start < length; start += width {
+			end := start + width
+			if end > length {
+				end = length
+			}
+			input.Write(seq[start:end])
+			input.WriteByte('\n')
+		}
+	}
+	return input.Bytes()
+}
+
+// benchmarkRead measures the time taken to read all the records of the input
+func benchmarkRead(b *testing.B, input []byte) {
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader := NewReader(bytes.NewReader(input))
+		for reader.Scan() {
+		}
+		if err := reader.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// chromosomeLength is the length of the large record of benchmarks, the size of a human chromosome
+const chromosomeLength = 250_000_000
+
+func BenchmarkSingleLineRecord(b *testing.B) {
+	benchmarkRead(b, benchmarkInput(1, chromosomeLength, 0))
+}
+
+func BenchmarkSingleLineScaling(b *testing.B) {
+	// reading time is linear in the length of the line: the throughput is the same for all sizes
+	for _, scale := range []int{1, 4, 16} {
+		length := scale * chromosomeLength / 16
+		b.Run(fmt.Sprintf("%dx", scale), func(b *testing.B) {
+			benchmarkRead(b, benchmarkInput(1, length, 0))
+		})
+	}
+}
+
+func BenchmarkWrappedRecord60(b *testing.B) {
+	benchmarkRead(b, benchmarkInput(1, chromosomeLength, 60))
+}
+
+func BenchmarkWrappedRecord80(b *testing.B) {
+	benchmarkRead(b, benchmarkInput(1, chromosomeLength, 80))
+}
+
+func BenchmarkShortRecords(b *testing.B) {
+	benchmarkRead(b, benchmarkInput(5_000_000, 50, 0))
+}
+
+func TestSetIDKeepsDescription(t *testing.T) {
+	for _, name := range []string{"a", "a desc", "a\tdesc  x", "a \t desc"} {
+		var record SeqRecord
+		record.SetName(name)
+		record.SetID("p_" + record.ID)
+		if want := "p_" + name; record.Name != want {
+			t.Errorf("SetID on %q: got %q, want %q", name, record.Name, want)
+		}
+	}
+
+	var record SeqRecord
+	record.SetName("a\tdesc")
+	record.SetDescription("other")
+	if record.Name != "a\tother" {
+		t.Errorf("SetDescription: got %q, want %q", record.Name, "a\tother")
+	}
+
+	record = SeqRecord{ID: "a"}
+	record.SetDescription("desc")
+	if record.Name != "a desc" {
+		t.Errorf("SetDescription without separator: got %q, want %q", record.Name, "a desc")
+	}
+}
@@ -0,0 +1,77 @@
+package seqs
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Writer writes SeqRecords to a buffered output stream, in fasta or fastq format.
+// Fasta sequences are wrapped every LineWidth characters, or written on a single line
+// if LineWidth is 0. When CRLF is set lines are terminated by "\r\n" instead of "\n".
+// Flush must be called once all the records are written.
+type Writer struct {
+	Format    Format
+	LineWidth int
+	CRLF      bool
+
+	output *bufio.Writer
+}
+
+// NewWriter returns a Writer writing records in the given format to the `output` stream,
+// with sequences wrapped at 80 characters.
+func NewWriter(output io.Writer, format Format) *Writer {
+	return &Writer{
+		Format:    format,
+		LineWidth: 80,
+		output:    bufio.NewWriterSize(output, 1<<16),
+	}
+}
+
+// Write writes a single record to the output stream
+func (w *Writer) Write(record SeqRecord) error {
+	if w.LineWidth < 0 {
+		return errors.New("width of fasta line must be >= 0")
+	}
+
+	if w.Format == FASTQ {
+		w.writeLine("@", record.Name)
+		w.writeLine("", string(record.Seq))
+		w.writeLine("+", "")
+		return w.writeLine("", record.Qual)
+	}
+
+	w.writeLine(">", record.Name)
+
+	seq := string(record.Seq)
+	if w.LineWidth == 0 || len(seq) <= w.LineWidth {
+		return w.writeLine("", seq)
+	}
+
+	var err error
+	for start := 0; start < len(seq) && err == nil; start += w.LineWidth {
+		end := start + w.LineWidth
+		if end > len(seq) {
+			end = len(seq)
+		}
+		err = w.writeLine("", seq[start:end])
+	}
+
+	return err
+}
+
+// writeLine writes the prefix and content to the buffered output followed by a line terminator.
+// Since bufio.Writer errors are sticky, checking the error of the last call is enough.
+func (w *Writer) writeLine(prefix string, content string) error {
+	w.output.WriteString(prefix)
+	w.output.WriteString(content)
+	if w.CRLF {
+		w.output.WriteByte('\r')
+	}
+	return w.output.WriteByte('\n')
+}
+
+// Flush writes any buffered data to the underlying output stream
+func (w *Writer) Flush() error {
+	return w.output.Flush()
+}